@@ -61,10 +61,9 @@ type Variables []Variable
 
 // A Variable is the declaration of a GraphQL variable.
 type Variable struct {
-	Name     string
-	Type     string
-	Nullable bool
-	Default  Value
+	Name    string
+	Type    TypeDescriptor
+	Default Value
 }
 
 // A SelectionSet is a slice of Selection.
@@ -153,9 +152,14 @@ type AbstractTypeDefinition interface {
 	Field(string) (*TypeField, bool)
 }
 
+// A ScalarDefinition is a scalar type. Scalars declared with a base
+// type have the Kind of that type, while custom scalars, whose values
+// are converted by functions provided to the schema, have the Kind
+// reflect.Invalid.
 type ScalarDefinition struct {
-	Name string
-	Kind reflect.Kind
+	Name        string
+	Kind        reflect.Kind
+	SpecifiedBy string // The URL given by @specifiedBy, if any.
 }
 
 type EnumDefinition struct {
@@ -179,6 +183,14 @@ type UnionDefinition struct {
 	Members []TypeDescriptor
 }
 
+// An InputObjectDefinition is a named type for the objects given as
+// arguments. Its fields are declared like arguments, and so may have
+// default values.
+type InputObjectDefinition struct {
+	Name   string
+	Fields ArgumentDeclarations
+}
+
 type TypeFields []TypeField
 type TypeField struct {
 	Name      string
@@ -194,8 +206,9 @@ type TypeField struct {
 
 type ArgumentDeclarations []ArgumentDeclaration
 type ArgumentDeclaration struct {
-	Key  string
-	Type TypeDescriptor
+	Key     string
+	Type    TypeDescriptor
+	Default Value // The value of the argument when it is omitted, or nil.
 }
 
 func (obj *InterfaceDefinition) Field(name string) (*TypeField, bool) {
@@ -204,6 +217,15 @@ func (obj *InterfaceDefinition) Field(name string) (*TypeField, bool) {
 func (obj *ObjectDefinition) Field(name string) (*TypeField, bool) {
 	return findTypeField(obj.Fields, name)
 }
+func (obj *InputObjectDefinition) Field(name string) (*ArgumentDeclaration, bool) {
+	for i, field := range obj.Fields {
+		if field.Key == name {
+			return &obj.Fields[i], true
+		}
+	}
+
+	return nil, false
+}
 func findTypeField(fields []TypeField, name string) (*TypeField, bool) {
 	for i, field := range fields {
 		if field.Name == name {
@@ -239,25 +261,28 @@ type InputObjectType struct {
 
 // Interface implementations
 
-func (*FragmentDefinition) definition()  {}
-func (*OperationDefinition) definition() {}
-func (*ScalarDefinition) definition()    {}
-func (*EnumDefinition) definition()      {}
-func (*ObjectDefinition) definition()    {}
-func (*InterfaceDefinition) definition() {}
-func (*UnionDefinition) definition()     {}
-
-func (d *ScalarDefinition) typeDefinition()    {}
-func (d *EnumDefinition) typeDefinition()      {}
-func (d *ObjectDefinition) typeDefinition()    {}
-func (d *InterfaceDefinition) typeDefinition() {}
-func (d *UnionDefinition) typeDefinition()     {}
-
-func (d *ScalarDefinition) TypeName() string    { return d.Name }
-func (d *EnumDefinition) TypeName() string      { return d.Name }
-func (d *ObjectDefinition) TypeName() string    { return d.Name }
-func (d *InterfaceDefinition) TypeName() string { return d.Name }
-func (d *UnionDefinition) TypeName() string     { return d.Name }
+func (*FragmentDefinition) definition()    {}
+func (*OperationDefinition) definition()   {}
+func (*ScalarDefinition) definition()      {}
+func (*EnumDefinition) definition()        {}
+func (*ObjectDefinition) definition()      {}
+func (*InterfaceDefinition) definition()   {}
+func (*UnionDefinition) definition()       {}
+func (*InputObjectDefinition) definition() {}
+
+func (d *ScalarDefinition) typeDefinition()      {}
+func (d *EnumDefinition) typeDefinition()        {}
+func (d *ObjectDefinition) typeDefinition()      {}
+func (d *InterfaceDefinition) typeDefinition()   {}
+func (d *UnionDefinition) typeDefinition()       {}
+func (d *InputObjectDefinition) typeDefinition() {}
+
+func (d *ScalarDefinition) TypeName() string      { return d.Name }
+func (d *EnumDefinition) TypeName() string        { return d.Name }
+func (d *ObjectDefinition) TypeName() string      { return d.Name }
+func (d *InterfaceDefinition) TypeName() string   { return d.Name }
+func (d *UnionDefinition) TypeName() string       { return d.Name }
+func (d *InputObjectDefinition) TypeName() string { return d.Name }
 
 func (*Field) selection()              {}
 func (*FragmentSpread) selection()     {}
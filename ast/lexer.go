@@ -3,7 +3,10 @@ package ast
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
+	"strconv"
+	"strings"
 )
 
 type pos uint64
@@ -149,7 +152,7 @@ func (l *lexer) scanNumber() (tok token, lit string) {
 func (l *lexer) scanComment() (tok token, lit string) {
 	buf := new(bytes.Buffer)
 
-	for ch := l.read(); !isLineTerminator(ch); ch = l.read() {
+	for ch := l.read(); !isLineTerminator(ch) && ch != eof; ch = l.read() {
 		buf.WriteRune(ch)
 	}
 
@@ -167,11 +170,17 @@ func (l *lexer) scanString() (tok token, lit string) {
 		if isLineTerminator(ch) {
 			return tokenIllegal, "Line terminator in string literal"
 		}
+		if ch == eof {
+			return tokenIllegal, "Unterminated string literal"
+		}
 
 		// Consume escaped characters
 		if ch == '\\' {
 			buf.WriteRune(ch)
 			ch = l.read()
+			if ch == eof {
+				return tokenIllegal, "Unterminated string literal"
+			}
 		}
 
 		buf.WriteRune(ch)
@@ -181,6 +190,58 @@ func (l *lexer) scanString() (tok token, lit string) {
 	return tokenStringValue, buf.String()
 }
 
+// Unescape returns the string which a string literal represents. The
+// lexer keeps the escape sequences of a literal as they were written,
+// so they are replaced here, once the literal is used as a value.
+func (v StringValue) Unescape() (string, error) {
+	s := string(v)
+	if !strings.ContainsRune(s, '\\') {
+		return s, nil
+	}
+
+	buf := new(bytes.Buffer)
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			buf.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("Unterminated escape sequence in string \"%s\"", s)
+		}
+
+		switch s[i] {
+		case '"', '\\', '/':
+			buf.WriteByte(s[i])
+		case 'b':
+			buf.WriteByte('\b')
+		case 'f':
+			buf.WriteByte('\f')
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 't':
+			buf.WriteByte('\t')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", fmt.Errorf("Invalid unicode escape sequence in string \"%s\"", s)
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("Invalid unicode escape sequence in string \"%s\"", s)
+			}
+			buf.WriteRune(rune(r))
+			i += 4
+		default:
+			return "", fmt.Errorf("Invalid escape sequence '\\%c' in string \"%s\"", s[i], s)
+		}
+	}
+
+	return buf.String(), nil
+}
+
 func (l *lexer) last() (tok token, lit string) {
 	return l.lastToken, l.lastLiteral
 }
@@ -288,6 +349,12 @@ func (l *lexer) Discard() {
 	l.lastSuccess = false
 }
 
+// Backup causes the last token to be returned again by the next call
+// to Optional, Expect or Advance.
+func (l *lexer) Backup() {
+	l.lastSuccess = false
+}
+
 func (l *lexer) Expect(expected token) bool {
 	tok, _ := l.last()
 	if l.lastSuccess {
@@ -14,6 +14,9 @@ var lexTests = map[string]lexTest{
 	"empty":  {"", []token{tokenEOF}},
 	"spaces": {" \t\n", []token{tokenIgnored, tokenEOF}},
 	"string": {`"\"what's up\", he said."`, []token{tokenStringValue, tokenEOF}},
+	"unterminatedString": {`{ a(x: "abc`, []token{tokenLeftCurly, tokenIgnored, tokenIdent, tokenLeftParen,
+		tokenIdent, tokenColon, tokenIgnored, tokenIllegal}},
+	"unterminatedEscape": {`"abc\`, []token{tokenIllegal}},
 	"simple": {
 		`{
 		  user(id: 4) {
@@ -53,6 +56,7 @@ var lexTests = map[string]lexTest{
 			tokenEOF,
 		},
 	},
+	"comment": {"# A comment may end the document", []token{tokenComment, tokenEOF}},
 }
 
 func compare(a, b []token) bool {
@@ -76,8 +76,13 @@ func FromReader(r io.Reader) (Document, error) {
 			if err := parseObjectDefinition(def, lex); err != nil {
 				return doc, err
 			}
+		case "input":
+			def := &InputObjectDefinition{}
+			doc.Definitions = append(doc.Definitions, def)
+			if err := parseInputObjectDefinition(def, lex); err != nil {
+				return doc, err
+			}
 		default:
-			panic(lit)
 			return doc, errors.New("Invalid identifier to begin definition")
 		}
 	}
@@ -344,7 +349,7 @@ func parseVariableDefinitions(vars *Variables, lex *lexer) error {
 		case tokenEOF:
 			return errors.New("Unexpected end of file")
 		case tokenVariableValue:
-			v := &Variable{Nullable: true}
+			v := &Variable{}
 			_, v.Name = lex.last()
 
 			// Type
@@ -352,20 +357,11 @@ func parseVariableDefinitions(vars *Variables, lex *lexer) error {
 				return errors.New("Variable without type")
 			}
 
-			// Type name
-			switch tok, lit := lex.Advance(); tok {
-			case tokenIdent:
-				v.Type = lit
-			case tokenLeftBracket:
-				return errors.New("TODO: List not yet supported")
-			default:
-				return errors.New("Variable without type")
-			}
-
-			// Non null variable check
-			if lex.Optional(tokenExclam) {
-				v.Nullable = false
+			t, err := parseType(lex)
+			if err != nil {
+				return err
 			}
+			v.Type = t
 
 			// Default Value(Optional)
 			if lex.Optional(tokenEqual) {
@@ -453,6 +449,8 @@ func parseValue(lex *lexer) (Value, error) {
 			return nil, err
 		}
 		return list, nil
+	case tokenIllegal:
+		return nil, errors.New(lit)
 	default:
 		return nil, errors.New("Invalid value")
 	}
@@ -12,6 +12,12 @@ type ParseTest struct {
 
 var parseTests = map[string]ParseTest{
 	"empty": {""},
+	"Comments": {`
+		# A comment may begin a line
+		{
+			id # or follow a field
+		}
+		# or end the document`},
 	"Unnamed": {`
 		{
 			id,
@@ -64,6 +70,13 @@ var parseTests = map[string]ParseTest{
 			}
 		}
 	`},
+	"ListVariableQuery": {`
+		query FetchHumans($ids: [ID!]!, $episode: Episode = JEDI, $first: Int) {
+			humans(ids: $ids, episode: $episode, first: $first) {
+				name
+			}
+		}
+	`},
 	"AliasedQuery": {`
 		query FetchLukeAliased {
 			luke: human(id: "1000") {
@@ -131,6 +144,11 @@ var parseTests = map[string]ParseTest{
 	"ScalarType": {`
 		scalar URL String
 	`},
+	"CustomScalarType": {`
+		scalar DateTime @specifiedBy(url: "https://tools.ietf.org/html/rfc3339")
+		scalar Money
+		type Payment { amount: Money, at: DateTime }
+	`},
 	"EnumType": {`
 		enum Movie { NEWHOPE, EMPIRE, JEDI }
 	`},
@@ -152,6 +170,20 @@ var parseTests = map[string]ParseTest{
 			playlists(first: Int, after: Id, last: Int, before: Id): PlaylistConnection
 		}
 	`},
+	"InputObjectType": {`
+		input Review {
+			stars: Int!
+			commentary: String = ""
+			tags: [String!]
+		}
+	`},
+	"ArgumentDefault": {`
+		# Arguments may declare the value used when they are omitted
+		type Dog {
+			isHouseTrained(atOtherHomes: Boolean = false): Boolean
+			commands(names: [String] = ["sit", "stay"], limit: Int = 10): [String]
+		}
+	`},
 }
 
 func TestParser(t *testing.T) {
@@ -85,6 +85,39 @@ func parseInterfaceDefinition(def *InterfaceDefinition, lex *lexer) error {
 	return nil
 }
 
+func parseInputObjectDefinition(def *InputObjectDefinition, lex *lexer) error {
+	if !lex.Expect(tokenIdent) {
+		return errors.New("Expected name in input declaration")
+	}
+
+	_, def.Name = lex.last()
+
+	if !lex.Expect(tokenLeftCurly) {
+		return errors.New("Input declaration must have a body")
+	}
+
+	cnt := 0
+	for lex.Optional(tokenIdent) {
+		cnt++
+		_, key := lex.last()
+		field := ArgumentDeclaration{Key: key}
+		if err := parseInputValue(&field, lex); err != nil {
+			return err
+		}
+		def.Fields = append(def.Fields, field)
+	}
+
+	if cnt == 0 {
+		return errors.New("Input declaration must have at least one field")
+	}
+
+	if !lex.Expect(tokenRightCurly) {
+		return errors.New("Invalid input field declaration")
+	}
+
+	return nil
+}
+
 func parseEnumDefinition(def *EnumDefinition, lex *lexer) error {
 	if !lex.Expect(tokenIdent) {
 		return errors.New("Expected name in enum declaration")
@@ -149,21 +182,46 @@ func parseScalarDefinition(def *ScalarDefinition, lex *lexer) error {
 
 	_, def.Name = lex.last()
 
-	if !lex.Expect(tokenIdent) {
-		return errors.New("Expected base type of new scalar")
-	}
-
-	switch _, lit := lex.last(); lit {
-	case "Int":
-		def.Kind = reflect.Int
-	case "Float":
-		def.Kind = reflect.Float64
-	case "String":
-		def.Kind = reflect.String
-	case "Boolean":
-		def.Kind = reflect.Bool
-	default:
-		return errors.New("Unknown base type for scalar")
+	// Base type (Optional)
+	if lex.Optional(tokenIdent) {
+		switch _, lit := lex.last(); lit {
+		case "Int":
+			def.Kind = reflect.Int
+		case "Float":
+			def.Kind = reflect.Float64
+		case "String":
+			def.Kind = reflect.String
+		case "Boolean":
+			def.Kind = reflect.Bool
+		default:
+			// The name begins the next definition
+			lex.Backup()
+			return nil
+		}
+	}
+
+	// Directives (Optional)
+	if lex.Optional(tokenAt) {
+		dirs := Directives{}
+		if err := parseDirectives(&dirs, lex); err != nil {
+			return err
+		}
+
+		for _, dir := range dirs {
+			if dir.Name != "specifiedBy" {
+				return errors.New("Unknown directive on scalar: " + dir.Name)
+			}
+
+			for _, arg := range dir.Arguments {
+				if url, ok := arg.Value.(StringValue); ok && arg.Key == "url" {
+					def.SpecifiedBy = string(url)
+				}
+			}
+
+			if def.SpecifiedBy == "" {
+				return errors.New("@specifiedBy requires a url")
+			}
+		}
 	}
 
 	return nil
@@ -214,17 +272,10 @@ func parseArgumentDeclaration(args *ArgumentDeclarations, lex *lexer) error {
 			return errors.New("Unexpected end of file")
 		case tokenIdent:
 			arg := &ArgumentDeclaration{Key: lit}
-
-			if !lex.Expect(tokenColon) {
-				return errors.New("ArgumentType key without type")
+			if err := parseInputValue(arg, lex); err != nil {
+				return err
 			}
 
-			t, err := parseType(lex)
-			if err != nil {
-				return errors.New("Invalid type in ArgumentType")
-			}
-
-			arg.Type = t
 			*args = append(*args, *arg)
 		case tokenRightParen:
 			lex.Discard() // Advance lexer to next token
@@ -235,11 +286,41 @@ func parseArgumentDeclaration(args *ArgumentDeclarations, lex *lexer) error {
 	}
 }
 
+// parseInputValue parses the type and optional default value of an
+// argument or input object field whose name has already been read.
+func parseInputValue(arg *ArgumentDeclaration, lex *lexer) error {
+	if !lex.Expect(tokenColon) {
+		return errors.New("ArgumentType key without type")
+	}
+
+	t, err := parseType(lex)
+	if err != nil {
+		return errors.New("Invalid type in ArgumentType")
+	}
+
+	arg.Type = t
+
+	// Default Value (Optional)
+	if lex.Optional(tokenEqual) {
+		def, err := parseValue(lex)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := def.(VariableValue); ok {
+			return errors.New("Default value of argument cannot be a variable")
+		}
+		arg.Default = def
+	}
+
+	return nil
+}
+
 func parseType(lex *lexer) (TypeDescriptor, error) {
 	switch tok, lit := lex.Advance(); tok {
 	case tokenIdent:
 		t := &BaseType{name: lit}
-		t.nullable = lex.Optional(tokenExclam)
+		t.nullable = !lex.Optional(tokenExclam)
 		return t, nil
 
 	case tokenLeftBracket:
@@ -254,16 +335,12 @@ func parseType(lex *lexer) (TypeDescriptor, error) {
 			return t, errors.New("Unclosed List type")
 		}
 
-		t.nullable = lex.Optional(tokenExclam)
+		t.nullable = !lex.Optional(tokenExclam)
 		return t, nil
 
 	case tokenLeftCurly:
-		t := &InputObjectType{}
-		for {
-			if lex.Optional(tokenRightCurly) {
-				return t, nil
-			}
-
+		t := &InputObjectType{Fields: make(map[string]TypeDescriptor)}
+		for !lex.Optional(tokenRightCurly) {
 			if !lex.Expect(tokenIdent) {
 				return t, errors.New("InputObject type must have a key")
 			}
@@ -280,11 +357,7 @@ func parseType(lex *lexer) (TypeDescriptor, error) {
 			t.Fields[key] = item
 		}
 
-		if !lex.Expect(tokenRightCurly) {
-			return t, errors.New("Unclosed InputObject type")
-		}
-
-		t.nullable = lex.Optional(tokenExclam)
+		t.nullable = !lex.Optional(tokenExclam)
 		return t, nil
 
 	default:
@@ -0,0 +1,64 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTypeNullability(t *testing.T) {
+	doc, err := FromReader(strings.NewReader(`
+		type Query {
+			a: Int
+			b: Int!
+			c: [Int!]
+			d: [Int]!
+		}
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj, ok := doc.Definitions[0].(*ObjectDefinition)
+	if !ok {
+		t.Fatalf("Expected an object definition, got %T", doc.Definitions[0])
+	}
+
+	tests := map[string]struct{ nullable, itemNullable bool }{
+		"a": {true, false},
+		"b": {false, false},
+		"c": {true, false},
+		"d": {false, true},
+	}
+	for name, expect := range tests {
+		field, ok := obj.Field(name)
+		if !ok {
+			t.Fatalf("Field '%s' not found", name)
+		}
+
+		if field.Type.Nullable() != expect.nullable {
+			t.Errorf("%s: Expected nullable %t, got %t", name, expect.nullable, field.Type.Nullable())
+		}
+		if list, ok := field.Type.(*ListType); ok && list.OfType.Nullable() != expect.itemNullable {
+			t.Errorf("%s: Expected nullable items %t, got %t", name, expect.itemNullable, list.OfType.Nullable())
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	doc, err := FromReader(strings.NewReader(`
+		type Query {
+			f(a: String = "say \"hi\"\n", b: [String] = ["C:\\", "\u00e9\/"], c: Int = 5): Int
+		}
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	field, _ := doc.Definitions[0].(*ObjectDefinition).Field("f")
+	expect := []string{`"say \"hi\"\n"`, `["C:\\", "é/"]`, `5`}
+	for i, arg := range field.Arguments {
+		if actual := FormatValue(arg.Default); actual != expect[i] {
+			t.Errorf("%s: Expected %s, got %s", arg.Key, expect[i], actual)
+		}
+	}
+}
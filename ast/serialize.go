@@ -1,5 +1,102 @@
 package ast
 
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// FormatValue returns the GraphQL literal which represents v, as used
+// by introspection to describe default values.
+func FormatValue(v Value) string {
+	buf := new(bytes.Buffer)
+	formatValue(v, buf)
+	return buf.String()
+}
+
+func formatValue(v Value, buf *bytes.Buffer) {
+	switch v := v.(type) {
+	case VariableValue:
+		buf.WriteByte('$')
+		buf.WriteString(string(v))
+	case IntValue:
+		buf.WriteString(strconv.Itoa(int(v)))
+	case FloatValue:
+		buf.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 64))
+	case StringValue:
+		// String values retain the escape sequences they were written
+		// with, which may be invalid
+		s, err := v.Unescape()
+		if err != nil {
+			s = string(v)
+		}
+		quoteString(s, buf)
+	case EnumValue:
+		buf.WriteString(string(v))
+	case BooleanValue:
+		buf.WriteString(strconv.FormatBool(bool(v)))
+	case ListValue:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i != 0 {
+				buf.WriteString(", ")
+			}
+			formatValue(item, buf)
+		}
+		buf.WriteByte(']')
+	case ObjectValue:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i != 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(key)
+			buf.WriteString(": ")
+			formatValue(v[key], buf)
+		}
+		buf.WriteByte('}')
+	case nil:
+		buf.WriteString("null")
+	}
+}
+
+// quoteString writes s as a GraphQL string literal, escaping quotes,
+// backslashes and control characters.
+func quoteString(s string, buf *bytes.Buffer) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
 // func (node *Document) WriteTo(w io.Writer) (int64, error) {
 // return node.Definitions.WriteTo(w)
 // }
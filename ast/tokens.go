@@ -56,7 +56,7 @@ func isTerminal(tok token) bool {
 }
 
 func isIgnored(tok token) bool {
-	return tok >= tokenIgnored && tok <= tokenLineTerminator
+	return tok >= tokenIgnored && tok <= tokenComment
 }
 
 func isValue(tok token) bool {
@@ -15,7 +15,7 @@ func GetBaseType(desc TypeDescriptor) *BaseType {
 
 func IsAbstractType(def TypeDefinition) bool {
 	switch def.(type) {
-	case *EnumDefinition, *ScalarDefinition:
+	case *EnumDefinition, *ScalarDefinition, *InputObjectDefinition:
 		return false
 	default:
 		return true
@@ -24,7 +24,24 @@ func IsAbstractType(def TypeDefinition) bool {
 
 // Type Coercion
 
+// IsOfType reports whether v is a value of the given type. The fields
+// of an input object are only checked to be declared by the type, since
+// their own types cannot be looked up without the schema.
 func IsOfType(v Value, def TypeDefinition) bool {
+	if obj, ok := def.(*InputObjectDefinition); ok {
+		value, ok := v.(ObjectValue)
+		if !ok {
+			return false
+		}
+
+		for key := range value {
+			if _, ok := obj.Field(key); !ok {
+				return false
+			}
+		}
+		return true
+	}
+
 	if IsAbstractType(def) {
 		return false
 	}
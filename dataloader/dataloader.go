@@ -0,0 +1,254 @@
+// Package dataloader batches and caches the loading of values by the
+// resolvers of a single GraphQL request.
+//
+// Rather than waiting for a fixed amount of time to collect keys, a
+// Group counts the tasks of a request which are able to make progress.
+// A task which calls Load stops counting as active until its value has
+// been loaded. Once no task is active, every key requested since the
+// last dispatch is passed to its batch function at once, so each batch
+// function is called once per level of the response tree.
+package dataloader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+)
+
+// A Result is the value loaded for a single key, or the error which
+// prevented it from being loaded.
+type Result struct {
+	Value interface{}
+	Error error
+}
+
+// A BatchFunc loads the values of several keys at once. It must return
+// exactly one Result for each key, in the same order as keys.
+type BatchFunc func(c context.Context, keys []interface{}) []Result
+
+// A Group holds the Loaders of a single request and decides when they
+// are dispatched.
+type Group struct {
+	ctx     context.Context
+	batches map[string]BatchFunc
+
+	mu      sync.Mutex
+	active  int                // The number of tasks able to make progress.
+	waiting []chan struct{}    // Closed to wake each task waiting for the next dispatch.
+	loaders map[string]*Loader // The loaders created so far, by name.
+}
+
+// NewGroup returns a Group which creates its Loaders from the given
+// batch functions. c is passed to every call of a batch function.
+func NewGroup(c context.Context, batches map[string]BatchFunc) *Group {
+	return &Group{
+		ctx:     c,
+		batches: batches,
+		loaders: make(map[string]*Loader),
+	}
+}
+
+// Loader returns the Loader named name. It panics if the group has no
+// batch function of that name.
+func (g *Group) Loader(name string) *Loader {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if l, ok := g.loaders[name]; ok {
+		return l
+	}
+
+	batch, ok := g.batches[name]
+	if !ok {
+		log.Panicf("No loader named '%s' found", name)
+	}
+
+	l := &Loader{
+		name:  name,
+		group: g,
+		batch: batch,
+		cache: make(map[interface{}]*entry),
+	}
+	g.loaders[name] = l
+	return l
+}
+
+// Add adds delta, which may be negative, to the number of active tasks.
+// When the count reaches zero, all pending keys are dispatched in the
+// calling goroutine.
+func (g *Group) Add(delta int) {
+	g.mu.Lock()
+	g.active += delta
+	g.dispatchIfIdle()
+}
+
+// Done decrements the number of active tasks by one.
+func (g *Group) Done() {
+	g.Add(-1)
+}
+
+// dispatchIfIdle runs every pending batch if no task is active, then
+// wakes the waiting tasks. It must be called with g.mu held, and
+// releases it.
+func (g *Group) dispatchIfIdle() {
+	if g.active > 0 || len(g.waiting) == 0 {
+		g.mu.Unlock()
+		return
+	}
+
+	batches := make([]*batch, 0)
+	for _, l := range g.loaders {
+		if len(l.pending) == 0 {
+			continue
+		}
+
+		batches = append(batches, &batch{loader: l, keys: l.pending, entries: l.entries})
+		l.pending, l.entries = nil, nil
+	}
+	waiting := g.waiting
+	g.waiting = nil
+	g.mu.Unlock()
+
+	for _, b := range batches {
+		b.run(g.ctx)
+	}
+
+	// The woken tasks are counted as active before any of them resumes,
+	// so that the first to wait again does not dispatch its keys before
+	// the others have had a chance to add theirs.
+	g.mu.Lock()
+	g.active += len(waiting)
+	g.mu.Unlock()
+
+	for _, wake := range waiting {
+		close(wake)
+	}
+}
+
+// A Loader loads values by key using a batch function. Every value is
+// cached for the lifetime of the Loader, so each key is loaded at most
+// once per request.
+type Loader struct {
+	name  string
+	group *Group
+	batch BatchFunc
+
+	// Guarded by group.mu
+	cache   map[interface{}]*entry
+	pending []interface{} // Keys waiting to be dispatched.
+	entries []*entry      // The entries for the pending keys.
+}
+
+type entry struct {
+	done  chan struct{} // Closed once the entry has been loaded.
+	value interface{}
+	err   error
+}
+
+// Load returns the value for key, waiting until the next dispatch if
+// it has not been loaded yet. Keys must be comparable; a key which is
+// not, such as a slice, is never loaded and gives an error. Load must
+// only be called by an active task of the loader's Group, and never by
+// a batch function.
+func (l *Loader) Load(key interface{}) (interface{}, error) {
+	res := l.LoadMany([]interface{}{key})
+	return res[0].Value, res[0].Error
+}
+
+// LoadMany returns a Result for each of keys, waiting at most once for
+// those which have not been loaded yet.
+func (l *Loader) LoadMany(keys []interface{}) []Result {
+	// A key which cannot be hashed would panic while the lock is held,
+	// and so is rejected before taking it.
+	results := make([]Result, len(keys))
+	for i, key := range keys {
+		if key != nil && !reflect.ValueOf(key).Comparable() {
+			results[i].Error = fmt.Errorf("Loader '%s' cannot load key of type %T, which is not comparable", l.name, key)
+		}
+	}
+
+	g := l.group
+	g.mu.Lock()
+
+	wait := false
+	entries := make([]*entry, len(keys))
+	for i, key := range keys {
+		if results[i].Error != nil {
+			continue
+		}
+
+		e, ok := l.cache[key]
+		if !ok {
+			e = &entry{done: make(chan struct{})}
+			l.cache[key] = e
+			l.pending = append(l.pending, key)
+			l.entries = append(l.entries, e)
+		}
+
+		select {
+		case <-e.done:
+		default:
+			wait = true
+		}
+		entries[i] = e
+	}
+
+	if wait {
+		// The calling task cannot make progress until the keys have
+		// been dispatched.
+		wake := make(chan struct{})
+		g.waiting = append(g.waiting, wake)
+		g.active--
+		g.dispatchIfIdle()
+		<-wake
+	} else {
+		g.mu.Unlock()
+	}
+
+	for i, e := range entries {
+		if e != nil {
+			results[i] = Result{Value: e.value, Error: e.err}
+		}
+	}
+
+	return results
+}
+
+// A batch is a set of keys dispatched together to a Loader's batch
+// function.
+type batch struct {
+	loader  *Loader
+	keys    []interface{}
+	entries []*entry
+}
+
+func (b *batch) run(c context.Context) {
+	results, err := b.call(c)
+	if err == nil && len(results) != len(b.keys) {
+		err = fmt.Errorf("Batch function for '%s' returned %d results for %d keys",
+			b.loader.name, len(results), len(b.keys))
+	}
+
+	for i, e := range b.entries {
+		if err != nil {
+			e.err = err
+		} else {
+			e.value, e.err = results[i].Value, results[i].Error
+		}
+		close(e.done)
+	}
+}
+
+// call calls the batch function, recovering from a panic so that the
+// tasks waiting on the batch are not left waiting forever.
+func (b *batch) call(c context.Context) (results []Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Batch function for '%s' panicked: %v", b.loader.name, r)
+		}
+	}()
+
+	return b.loader.batch(c, b.keys), nil
+}
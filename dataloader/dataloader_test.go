@@ -0,0 +1,154 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// recorder is a batch function which records the keys of every call
+// and loads each key as its string representation.
+type recorder struct {
+	mu    sync.Mutex
+	calls [][]interface{}
+}
+
+func (rec *recorder) batch(c context.Context, keys []interface{}) []Result {
+	rec.mu.Lock()
+	rec.calls = append(rec.calls, keys)
+	rec.mu.Unlock()
+
+	results := make([]Result, len(keys))
+	for i, key := range keys {
+		if key == "bad" {
+			results[i].Error = errors.New("bad key")
+		} else {
+			results[i].Value = fmt.Sprint(key)
+		}
+	}
+	return results
+}
+
+// loadAll loads each of keys from a separate active task of g.
+func loadAll(g *Group, name string, keys ...interface{}) []Result {
+	results := make([]Result, len(keys))
+	wg := new(sync.WaitGroup)
+
+	g.Add(len(keys))
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i int, key interface{}) {
+			defer wg.Done()
+			defer g.Done()
+			results[i].Value, results[i].Error = g.Loader(name).Load(key)
+		}(i, key)
+	}
+
+	wg.Wait()
+	return results
+}
+
+func TestLoaderBatches(t *testing.T) {
+	rec := &recorder{}
+	g := NewGroup(context.Background(), map[string]BatchFunc{"User": rec.batch})
+
+	results := loadAll(g, "User", 1, 2, 3)
+	for i, res := range results {
+		if expect := fmt.Sprint(i + 1); res.Value != expect || res.Error != nil {
+			t.Errorf("Expected '%s', got '%v' (%v)", expect, res.Value, res.Error)
+		}
+	}
+
+	if len(rec.calls) != 1 || len(rec.calls[0]) != 3 {
+		t.Errorf("Expected a single batch of 3 keys, got %v", rec.calls)
+	}
+}
+
+func TestLoaderCache(t *testing.T) {
+	rec := &recorder{}
+	g := NewGroup(context.Background(), map[string]BatchFunc{"User": rec.batch})
+
+	loadAll(g, "User", 1, 1, 2)
+	loadAll(g, "User", 2, 3)
+
+	if len(rec.calls) != 2 || len(rec.calls[0]) != 2 || len(rec.calls[1]) != 1 {
+		t.Errorf("Expected each key to be loaded once, got %v", rec.calls)
+	}
+}
+
+func TestLoaderErrors(t *testing.T) {
+	rec := &recorder{}
+	g := NewGroup(context.Background(), map[string]BatchFunc{
+		"User": rec.batch,
+		"Short": func(c context.Context, keys []interface{}) []Result {
+			return nil
+		},
+		"Panic": func(c context.Context, keys []interface{}) []Result {
+			panic("oops")
+		},
+	})
+
+	results := loadAll(g, "User", "good", "bad")
+	if results[0].Error != nil || results[1].Error == nil {
+		t.Errorf("Expected an error only for the bad key, got %v", results)
+	}
+
+	for _, name := range []string{"Short", "Panic"} {
+		for _, res := range loadAll(g, name, 1, 2) {
+			if res.Error == nil {
+				t.Errorf("%s: Expected an error for every key", name)
+			}
+		}
+	}
+}
+
+func TestLoaderUncomparableKeys(t *testing.T) {
+	rec := &recorder{}
+	g := NewGroup(context.Background(), map[string]BatchFunc{"User": rec.batch})
+
+	type wrapper struct{ key interface{} }
+	results := loadAll(g, "User", 1, []int{2}, map[string]int{"id": 3}, wrapper{[]int{4}}, wrapper{5})
+	for i, res := range results {
+		if valid := i == 0 || i == 4; (res.Error == nil) != valid {
+			t.Errorf("Key %d: Expected an error only for an uncomparable key, got '%v' (%v)", i, res.Value, res.Error)
+		}
+	}
+
+	// The group is still usable once the keys have been rejected
+	if res := loadAll(g, "User", 6); res[0].Value != "6" {
+		t.Errorf("Expected '6', got '%v' (%v)", res[0].Value, res[0].Error)
+	}
+
+	if len(rec.calls) != 2 || len(rec.calls[0]) != 2 {
+		t.Errorf("Expected only the comparable keys to be loaded, got %v", rec.calls)
+	}
+}
+
+func TestLoadMany(t *testing.T) {
+	rec := &recorder{}
+	g := NewGroup(context.Background(), map[string]BatchFunc{"User": rec.batch})
+
+	g.Add(1)
+	results := g.Loader("User").LoadMany([]interface{}{1, 2, 1})
+	g.Done()
+
+	if len(results) != 3 || results[0].Value != "1" || results[1].Value != "2" || results[2].Value != "1" {
+		t.Errorf("Unexpected results %v", results)
+	}
+
+	if len(rec.calls) != 1 || len(rec.calls[0]) != 2 {
+		t.Errorf("Expected a single batch of 2 keys, got %v", rec.calls)
+	}
+}
+
+func TestLoaderUnknown(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected unknown loader to panic")
+		}
+	}()
+
+	NewGroup(context.Background(), nil).Loader("User")
+}
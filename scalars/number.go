@@ -0,0 +1,183 @@
+package scalars
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"dylanmackenzie.com/graphql/ast"
+	"dylanmackenzie.com/graphql/schema"
+)
+
+// bigInt is the BigInt scalar. It is given as an integer or a string of
+// digits, since integer literals cannot hold every value exactly, and is
+// serialized as a string. Resolvers may also set its fields to any Go
+// integer.
+var bigInt = schema.Scalar{
+	ParseLiteral: func(v ast.Value) (interface{}, error) {
+		if i, ok := v.(ast.IntValue); ok {
+			return big.NewInt(int64(i)), nil
+		}
+		return parseBigInt(v.Value())
+	},
+	ParseValue: parseBigInt,
+	Serialize: func(v interface{}) (interface{}, error) {
+		switch i := v.(type) {
+		case *big.Int:
+			return i.String(), nil
+		case big.Int:
+			return i.String(), nil
+		case int:
+			return strconv.Itoa(i), nil
+		case int32:
+			return strconv.FormatInt(int64(i), 10), nil
+		case int64:
+			return strconv.FormatInt(i, 10), nil
+		case uint64:
+			return strconv.FormatUint(i, 10), nil
+		}
+
+		return nil, fmt.Errorf("Expected a *big.Int or integer, got %T", v)
+	},
+}
+
+func parseBigInt(v interface{}) (interface{}, error) {
+	switch i := v.(type) {
+	case json.Number:
+		n, ok := new(big.Int).SetString(i.String(), 10)
+		if !ok {
+			return nil, fmt.Errorf("Expected an integer, got %s", describe(v))
+		}
+		return n, nil
+
+	case float64:
+		if i != math.Trunc(i) || math.Abs(i) > 1<<53 {
+			return nil, fmt.Errorf("Expected an exact integer, got %s; give large integers as strings", describe(v))
+		}
+		return big.NewInt(int64(i)), nil
+
+	case string:
+		n, ok := new(big.Int).SetString(i, 10)
+		if !ok {
+			return nil, fmt.Errorf("Expected a string of decimal digits, got %q", i)
+		}
+		return n, nil
+	}
+
+	return nil, fmt.Errorf("BigInt must be given as an integer or string, got %s", describe(v))
+}
+
+// maxDecimalPlaces bounds the number of digits written after the point
+// of a Decimal, which is only exact if the value has a finite decimal
+// representation.
+const maxDecimalPlaces = 100
+
+// decimal is the Decimal scalar. It is given as a number or a string
+// such as "12.50", and serialized as a string. A JSON number is read
+// exactly, while a float literal is read as the shortest decimal which
+// it is the nearest float64 to, so that 0.1 is exactly one tenth, and
+// literals with more than 15 significant digits must be given as
+// strings. Resolvers may also set its fields to a float64, a string or
+// a *big.Float.
+var decimal = schema.Scalar{
+	ParseLiteral: func(v ast.Value) (interface{}, error) {
+		switch d := v.(type) {
+		case ast.IntValue:
+			return new(big.Rat).SetInt64(int64(d)), nil
+		case ast.FloatValue:
+			return parseDecimalFloat(float64(d))
+		case ast.StringValue:
+			s, err := d.Unescape()
+			if err != nil {
+				return nil, err
+			}
+			return parseDecimal(s)
+		}
+
+		return nil, fmt.Errorf("Decimal must be given as a number or string, got %s", describe(v.Value()))
+	},
+	ParseValue: func(v interface{}) (interface{}, error) {
+		switch d := v.(type) {
+		case json.Number:
+			return parseDecimal(d.String())
+		case float64:
+			return parseDecimalFloat(d)
+		case string:
+			return parseDecimal(d)
+		}
+
+		return nil, fmt.Errorf("Decimal must be given as a number or string, got %s", describe(v))
+	},
+	Serialize: func(v interface{}) (interface{}, error) {
+		switch d := v.(type) {
+		case *big.Rat:
+			return formatDecimal(d)
+		case *big.Float:
+			return d.Text('f', -1), nil
+		case float64:
+			if math.IsNaN(d) || math.IsInf(d, 0) {
+				return nil, fmt.Errorf("%g is not a finite decimal", d)
+			}
+			return strconv.FormatFloat(d, 'f', -1, 64), nil
+		case string:
+			r, err := parseDecimal(d)
+			if err != nil {
+				return nil, err
+			}
+			return formatDecimal(r)
+		}
+
+		return nil, fmt.Errorf("Expected a *big.Rat, *big.Float, float64 or string, got %T", v)
+	},
+}
+
+func parseDecimal(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("Expected a decimal number such as \"12.50\", got %q", s)
+	}
+	if _, err := formatDecimal(r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// parseDecimalFloat converts a number to the decimal it was written as,
+// rather than to the binary fraction which holds it.
+func parseDecimalFloat(d float64) (*big.Rat, error) {
+	if math.IsNaN(d) || math.IsInf(d, 0) {
+		return nil, fmt.Errorf("%g is not a finite decimal", d)
+	}
+
+	return parseDecimal(strconv.FormatFloat(d, 'g', -1, 64))
+}
+
+// formatDecimal writes a rational number with as few decimal places as
+// represent it exactly.
+func formatDecimal(r *big.Rat) (string, error) {
+	denom := new(big.Int).Set(r.Denom())
+	places := 0
+	for _, factor := range []int64{2, 5} {
+		n, mod := 0, new(big.Int)
+		f := big.NewInt(factor)
+		for {
+			q, m := new(big.Int).QuoRem(denom, f, mod)
+			if m.Sign() != 0 {
+				break
+			}
+			denom, n = q, n+1
+		}
+		if n > places {
+			places = n
+		}
+	}
+
+	if denom.Cmp(big.NewInt(1)) != 0 || places > maxDecimalPlaces {
+		return "", fmt.Errorf("%s has no exact decimal representation", r.RatString())
+	}
+
+	return r.FloatString(places), nil
+}
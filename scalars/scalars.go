@@ -0,0 +1,102 @@
+// Package scalars provides custom scalar types which are commonly
+// needed by schemas, so that they need not be written for each one.
+//
+//	DateTime   time.Time      RFC 3339 date and time, "2016-01-02T15:04:05Z"
+//	Date       time.Time      RFC 3339 full-date, "2016-01-02"
+//	Time       time.Time      RFC 3339 partial-time, "15:04:05"
+//	Duration   time.Duration  ISO 8601 duration in days or less, "PT1H30M"
+//	UUID       string         RFC 4122 UUID in lowercase
+//	JSON       interface{}    Any JSON value
+//	BigInt     *big.Int       Integer of any size, serialized as a string
+//	Decimal    *big.Rat       Exact decimal number, serialized as a string
+//	Email      string         RFC 5322 address without a display name
+//	URL        *url.URL       Absolute URL
+//	Base64     []byte         Standard base64 encoding with padding
+//
+// The Go type of each scalar is the type passed to resolvers as its
+// arguments. Resolvers may set fields to values of that type, or of
+// the other types documented for each scalar.
+package scalars
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"dylanmackenzie.com/graphql/ast"
+	"dylanmackenzie.com/graphql/schema"
+)
+
+// A definition is a scalar along with the URL of its specification, if
+// it follows a published one.
+type definition struct {
+	specifiedBy string
+	scalar      schema.Scalar
+}
+
+var definitions = map[string]definition{
+	"DateTime": {"https://scalars.graphql.org/andimarek/date-time", dateTime},
+	"Date":     {"https://tools.ietf.org/html/rfc3339#section-5.6", date},
+	"Time":     {"https://tools.ietf.org/html/rfc3339#section-5.6", timeOfDay},
+	"Duration": {"https://en.wikipedia.org/wiki/ISO_8601#Durations", duration},
+	"UUID":     {"https://tools.ietf.org/html/rfc4122", uuid},
+	"JSON":     {"https://www.json.org", jsonValue},
+	"BigInt":   {scalar: bigInt},
+	"Decimal":  {scalar: decimal},
+	"Email":    {"https://tools.ietf.org/html/rfc5322#section-3.4.1", email},
+	"URL":      {"https://url.spec.whatwg.org", urlValue},
+	"Base64":   {"https://tools.ietf.org/html/rfc4648#section-4", base64Value},
+}
+
+// Names returns the names of every scalar provided by the package, in
+// alphabetical order.
+func Names() []string {
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Get returns the functions of the named scalar, for schemas which
+// declare it themselves.
+func Get(name string) (schema.Scalar, bool) {
+	def, ok := definitions[name]
+	return def.scalar, ok
+}
+
+// Add declares the named scalars in a schema and provides their
+// functions, or every scalar if no names are given. The scalars must
+// not already be declared by the schema. Add panics if a name is not
+// one of the scalars provided by the package.
+func Add(sch *schema.Schema, names ...string) {
+	if len(names) == 0 {
+		names = Names()
+	}
+
+	decls := new(strings.Builder)
+	for _, name := range names {
+		def, ok := definitions[name]
+		if !ok {
+			log.Panicf("No scalar named '%s' found", name)
+		}
+
+		decls.WriteString("scalar " + name)
+		if def.specifiedBy != "" {
+			decls.WriteString(` @specifiedBy(url: "` + def.specifiedBy + `")`)
+		}
+		decls.WriteString("\n")
+	}
+
+	doc, err := ast.FromReader(strings.NewReader(decls.String()))
+	if err != nil {
+		log.Panicf("Invalid scalar declarations: %s", err)
+	}
+	sch.AddDocument(&doc)
+
+	for _, name := range names {
+		sch.AddScalar(name, definitions[name].scalar)
+	}
+}
@@ -0,0 +1,236 @@
+package scalars
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"dylanmackenzie.com/graphql/ast"
+	"dylanmackenzie.com/graphql/schema"
+)
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		scalar string
+		in     interface{}
+		out    string // The serialized value, or "" if in is invalid
+	}{
+		{"DateTime", "2016-01-02T15:04:05.5+02:00", "2016-01-02T15:04:05.5+02:00"},
+		{"DateTime", "2016-01-02 15:04:05", ""},
+		{"DateTime", 5.0, ""},
+		{"Date", "2016-01-02", "2016-01-02"},
+		{"Date", "2016-02-30", ""},
+		{"Time", "15:04:05.25", "15:04:05.25"},
+		{"Time", "25:00:00", ""},
+		{"Duration", "P1DT2H30M", "P1DT2H30M"},
+		{"Duration", "PT90M", "PT1H30M"},
+		{"Duration", "-PT0.5S", "-PT0.5S"},
+		{"Duration", "P1W", "P7D"},
+		{"Duration", "P1M", ""},
+		{"Duration", "PT", ""},
+		{"Duration", "1h", ""},
+		{"Duration", "P999999999999D", ""},
+		{"Duration", "-P999999999999D", ""},
+		{"Duration", "P106751D", "P106751D"},
+		{"UUID", "123E4567-E89B-12D3-A456-426614174000", "123e4567-e89b-12d3-a456-426614174000"},
+		{"UUID", "123e4567e89b12d3a456426614174000", ""},
+		{"BigInt", "123456789012345678901234567890", "123456789012345678901234567890"},
+		{"BigInt", 42.0, "42"},
+		{"BigInt", json.Number("12345678901234567890"), "12345678901234567890"},
+		{"BigInt", json.Number("4.2"), ""},
+		{"BigInt", 4.2, ""},
+		{"BigInt", "0x10", ""},
+		{"Decimal", "12.50", "12.5"},
+		{"Decimal", 0.25, "0.25"},
+		{"Decimal", 0.1, "0.1"},
+		{"Decimal", json.Number("0.10000000000000000001"), "0.10000000000000000001"},
+		{"Decimal", 12.10, "12.1"},
+		{"Decimal", "1/3", ""},
+		{"Decimal", true, ""},
+		{"Email", "luke@example.com", "luke@example.com"},
+		{"Email", "Luke <luke@example.com>", ""},
+		{"Email", "luke", ""},
+		{"URL", "https://example.com/a?b=c", "https://example.com/a?b=c"},
+		{"URL", "/relative", ""},
+		{"Base64", "aGVsbG8=", "aGVsbG8="},
+		{"Base64", "aGVsbG8", ""},
+	}
+
+	for _, test := range tests {
+		s, ok := Get(test.scalar)
+		if !ok {
+			t.Fatalf("No scalar '%s'", test.scalar)
+		}
+
+		v, err := s.ParseValue(test.in)
+		if test.out == "" {
+			if err == nil {
+				t.Errorf("%s %v: Expected an error, got %v", test.scalar, test.in, v)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %v: %s", test.scalar, test.in, err)
+			continue
+		}
+
+		out, err := s.Serialize(v)
+		if err != nil {
+			t.Errorf("%s %v: %s", test.scalar, test.in, err)
+		} else if out != test.out {
+			t.Errorf("%s %v: Expected %q, got %q", test.scalar, test.in, test.out, out)
+		}
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	u, _ := url.Parse("https://example.com")
+	tests := []struct {
+		scalar string
+		in     interface{}
+		out    interface{} // nil if in is invalid
+	}{
+		{"DateTime", time.Date(2016, 1, 2, 15, 4, 5, 0, time.UTC), "2016-01-02T15:04:05Z"},
+		{"DateTime", "2016-01-02T15:04:05Z", nil},
+		{"Duration", time.Duration(0), "PT0S"},
+		{"Duration", 90 * time.Second, "PT1M30S"},
+		{"UUID", [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00},
+			"123e4567-e89b-12d3-a456-426614174000"},
+		{"UUID", "not-a-uuid", nil},
+		{"JSON", map[string]interface{}{"a": []int{1, 2}}, map[string]interface{}{"a": []int{1, 2}}},
+		{"JSON", func() {}, nil},
+		{"BigInt", int64(7), "7"},
+		{"BigInt", 7.0, nil},
+		{"Decimal", big.NewRat(1, 8), "0.125"},
+		{"Decimal", big.NewRat(1, 3), nil},
+		{"Email", "luke@example", "luke@example"},
+		{"URL", u, "https://example.com"},
+		{"URL", "example.com", nil},
+		{"Base64", []byte("hello"), "aGVsbG8="},
+	}
+
+	for _, test := range tests {
+		s, _ := Get(test.scalar)
+		out, err := s.Serialize(test.in)
+		if test.out == nil {
+			if err == nil {
+				t.Errorf("%s %v: Expected an error, got %v", test.scalar, test.in, out)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %v: %s", test.scalar, test.in, err)
+		} else if s, ok := out.(string); ok && s != test.out {
+			t.Errorf("%s %v: Expected %q, got %q", test.scalar, test.in, test.out, s)
+		}
+	}
+}
+
+var eventSchema = `
+type Event {
+  at: DateTime
+  length: Duration
+  attendees: BigInt
+  data: JSON
+}
+
+type Query {
+  event(at: DateTime!, length: Duration = "PT1H", attendees: BigInt, data: JSON): Event
+}
+`
+
+func TestAdd(t *testing.T) {
+	doc, err := ast.FromReader(strings.NewReader(eventSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sch := schema.New()
+	Add(sch, "DateTime", "Duration", "BigInt", "JSON")
+	sch.AddDocument(&doc)
+	sch.Root("query", "Query")
+	sch.AddResolveFunc("Event", func(r *schema.ResponseNode) {
+		for _, name := range []string{"at", "length", "attendees", "data"} {
+			v, _ := r.Args.Get(name)
+			r.Set(name, v)
+		}
+	})
+	sch.Finalize()
+
+	query := `{
+	  event(at: "2016-01-02T15:04:05Z", attendees: "12345678901234567890", data: {a: [1, "b"]}) {
+	    at length attendees data
+	  }
+	}`
+	q, err := ast.FromReader(strings.NewReader(query))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := schema.Execute(context.Background(), sch, &q, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := ctx.Response.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{"event":{"at":"2016-01-02T15:04:05Z","length":"PT1H","attendees":"12345678901234567890","data":{"a":[1,"b"]}}}`
+	if string(actual) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, actual)
+	}
+}
+
+func TestAddUnknown(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+
+	Add(schema.New(), "Money")
+}
+
+func TestBigIntLiteral(t *testing.T) {
+	for _, lit := range []ast.Value{ast.IntValue(42), ast.StringValue("42")} {
+		v, err := bigInt.ParseLiteral(lit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.(*big.Int).Int64() != 42 {
+			t.Errorf("Expected 42, got %v", v)
+		}
+	}
+
+	if _, err := bigInt.ParseLiteral(ast.FloatValue(4.2)); err == nil {
+		t.Error("Expected an error for a float literal")
+	}
+}
+
+func TestDecimalLiteral(t *testing.T) {
+	for lit, expect := range map[ast.Value]string{
+		ast.FloatValue(0.1):      "0.1",
+		ast.FloatValue(12.10):    "12.1",
+		ast.StringValue("12.10"): "12.1",
+		ast.IntValue(-3):         "-3",
+	} {
+		v, err := decimal.ParseLiteral(lit)
+		if err != nil {
+			t.Errorf("%v: %s", lit, err)
+			continue
+		}
+		if actual, _ := formatDecimal(v.(*big.Rat)); actual != expect {
+			t.Errorf("%v: Expected %s, got %s", lit, expect, actual)
+		}
+	}
+
+	if _, err := decimal.ParseLiteral(ast.BooleanValue(true)); err == nil {
+		t.Error("Expected an error for a boolean literal")
+	}
+}
@@ -0,0 +1,152 @@
+package scalars
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"dylanmackenzie.com/graphql/schema"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// uuid is the UUID scalar. Resolvers may also set its fields to a
+// [16]byte.
+var uuid = schema.Scalar{
+	ParseValue: func(v interface{}) (interface{}, error) {
+		s, err := stringInput(v, "UUID")
+		if err != nil {
+			return nil, err
+		}
+
+		if !uuidPattern.MatchString(s) {
+			return nil, fmt.Errorf("Expected a UUID such as \"123e4567-e89b-12d3-a456-426614174000\", got %q", s)
+		}
+		return strings.ToLower(s), nil
+	},
+	Serialize: func(v interface{}) (interface{}, error) {
+		switch id := v.(type) {
+		case string:
+			if !uuidPattern.MatchString(id) {
+				return nil, fmt.Errorf("%q is not a UUID", id)
+			}
+			return strings.ToLower(id), nil
+
+		case [16]byte:
+			return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16]), nil
+		}
+
+		return nil, fmt.Errorf("Expected a string or [16]byte, got %T", v)
+	},
+}
+
+// jsonValue is the JSON scalar, whose values are those produced by
+// encoding/json, with numbers as json.Number. Fields may be set to any
+// value which encoding/json can encode.
+var jsonValue = schema.Scalar{
+	ParseValue: func(v interface{}) (interface{}, error) {
+		return v, nil
+	},
+	Serialize: func(v interface{}) (interface{}, error) {
+		if _, err := json.Marshal(v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	},
+}
+
+// email is the Email scalar. Addresses are given and returned without
+// a display name or angle brackets.
+var email = schema.Scalar{
+	ParseValue: func(v interface{}) (interface{}, error) {
+		s, err := stringInput(v, "Email")
+		if err != nil {
+			return nil, err
+		}
+		return parseEmail(s)
+	},
+	Serialize: func(v interface{}) (interface{}, error) {
+		switch addr := v.(type) {
+		case string:
+			return parseEmail(addr)
+		case mail.Address:
+			return addr.Address, nil
+		case *mail.Address:
+			return addr.Address, nil
+		}
+
+		return nil, fmt.Errorf("Expected a string or mail.Address, got %T", v)
+	},
+}
+
+func parseEmail(s string) (string, error) {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s || addr.Name != "" {
+		return "", fmt.Errorf("Expected an email address such as \"luke@example.com\", got %q", s)
+	}
+
+	return addr.Address, nil
+}
+
+// urlValue is the URL scalar. Resolvers may also set its fields to a
+// url.URL or a string.
+var urlValue = schema.Scalar{
+	ParseValue: func(v interface{}) (interface{}, error) {
+		s, err := stringInput(v, "URL")
+		if err != nil {
+			return nil, err
+		}
+		return parseURL(s)
+	},
+	Serialize: func(v interface{}) (interface{}, error) {
+		switch u := v.(type) {
+		case *url.URL:
+			return u.String(), nil
+		case url.URL:
+			return u.String(), nil
+		case string:
+			parsed, err := parseURL(u)
+			if err != nil {
+				return nil, err
+			}
+			return parsed.String(), nil
+		}
+
+		return nil, fmt.Errorf("Expected a *url.URL or string, got %T", v)
+	},
+}
+
+func parseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil || !u.IsAbs() || u.Host == "" && u.Opaque == "" {
+		return nil, fmt.Errorf("Expected an absolute URL such as \"https://example.com/\", got %q", s)
+	}
+
+	return u, nil
+}
+
+var base64Value = schema.Scalar{
+	ParseValue: func(v interface{}) (interface{}, error) {
+		s, err := stringInput(v, "Base64")
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("Expected padded standard base64, got %q", s)
+		}
+		return b, nil
+	},
+	Serialize: func(v interface{}) (interface{}, error) {
+		b, ok := v.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("Expected a []byte, got %T", v)
+		}
+		return base64.StdEncoding.EncodeToString(b), nil
+	},
+}
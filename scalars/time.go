@@ -0,0 +1,246 @@
+package scalars
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"dylanmackenzie.com/graphql/schema"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	timeLayout = "15:04:05.999999999"
+)
+
+var dateTime = schema.Scalar{
+	ParseValue: func(v interface{}) (interface{}, error) {
+		s, err := stringInput(v, "DateTime")
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("Expected a date and time such as \"2016-01-02T15:04:05Z\", got %q", s)
+		}
+		return t, nil
+	},
+	Serialize: func(v interface{}) (interface{}, error) {
+		t, err := timeOutput(v)
+		if err != nil {
+			return nil, err
+		}
+		return t.Format(time.RFC3339Nano), nil
+	},
+}
+
+var date = schema.Scalar{
+	ParseValue: func(v interface{}) (interface{}, error) {
+		s, err := stringInput(v, "Date")
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := time.Parse(dateLayout, s)
+		if err != nil {
+			return nil, fmt.Errorf("Expected a date such as \"2016-01-02\", got %q", s)
+		}
+		return t, nil
+	},
+	Serialize: func(v interface{}) (interface{}, error) {
+		t, err := timeOutput(v)
+		if err != nil {
+			return nil, err
+		}
+		return t.Format(dateLayout), nil
+	},
+}
+
+// timeOfDay is the Time scalar. Its values are times on January 1st of
+// year 0, in UTC.
+var timeOfDay = schema.Scalar{
+	ParseValue: func(v interface{}) (interface{}, error) {
+		s, err := stringInput(v, "Time")
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := time.Parse(timeLayout, s)
+		if err != nil {
+			return nil, fmt.Errorf("Expected a time such as \"15:04:05\", got %q", s)
+		}
+		return t, nil
+	},
+	Serialize: func(v interface{}) (interface{}, error) {
+		t, err := timeOutput(v)
+		if err != nil {
+			return nil, err
+		}
+		return t.Format(timeLayout), nil
+	},
+}
+
+func timeOutput(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case *time.Time:
+		return *t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("Expected a time.Time, got %T", v)
+}
+
+var duration = schema.Scalar{
+	ParseValue: func(v interface{}) (interface{}, error) {
+		s, err := stringInput(v, "Duration")
+		if err != nil {
+			return nil, err
+		}
+		return parseDuration(s)
+	},
+	Serialize: func(v interface{}) (interface{}, error) {
+		switch d := v.(type) {
+		case time.Duration:
+			return formatDuration(d), nil
+		case *time.Duration:
+			return formatDuration(*d), nil
+		}
+
+		return nil, fmt.Errorf("Expected a time.Duration, got %T", v)
+	},
+}
+
+// parseDuration parses an ISO 8601 duration of days, hours, minutes and
+// seconds, such as "P1DT2H" or "-PT0.5S". Years and months are rejected
+// since their length varies.
+func parseDuration(s string) (time.Duration, error) {
+	invalid := fmt.Errorf("Expected an ISO 8601 duration such as \"PT1H30M\", got %q", s)
+
+	rest := s
+	negative := strings.HasPrefix(rest, "-")
+	rest = strings.TrimPrefix(rest, "-")
+	if !strings.HasPrefix(rest, "P") || len(rest) == 1 {
+		return 0, invalid
+	}
+	rest = rest[1:]
+
+	// The parts are summed as floats, which may exceed the range of a
+	// time.Duration without wrapping around
+	var d float64
+	inTime := false
+	units := map[bool]map[byte]time.Duration{
+		false: {'D': 24 * time.Hour, 'W': 7 * 24 * time.Hour},
+		true:  {'H': time.Hour, 'M': time.Minute, 'S': time.Second},
+	}
+
+	for rest != "" {
+		if rest[0] == 'T' {
+			if inTime || len(rest) == 1 {
+				return 0, invalid
+			}
+			inTime, rest = true, rest[1:]
+			continue
+		}
+
+		i := strings.IndexFunc(rest, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if i <= 0 {
+			return 0, invalid
+		}
+
+		n, err := strconv.ParseFloat(rest[:i], 64)
+		unit, ok := units[inTime][rest[i]]
+		if err != nil || !ok {
+			if rest[i] == 'Y' || (rest[i] == 'M' && !inTime) {
+				return 0, errors.New("Durations in years or months are not supported")
+			}
+			return 0, invalid
+		}
+
+		d += n * float64(unit)
+		rest = rest[i+1:]
+	}
+
+	if d >= math.MaxInt64 {
+		return 0, fmt.Errorf("Duration %q is too long for a time.Duration", s)
+	}
+	if negative {
+		d = -d
+	}
+	return time.Duration(d), nil
+}
+
+// formatDuration formats a duration in ISO 8601, using days, hours,
+// minutes and seconds.
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+
+	buf := new(strings.Builder)
+	if d < 0 {
+		buf.WriteString("-")
+		d = -d
+	}
+	buf.WriteString("P")
+
+	if days := d / (24 * time.Hour); days > 0 {
+		fmt.Fprintf(buf, "%dD", days)
+		d -= days * 24 * time.Hour
+	}
+
+	if d > 0 {
+		buf.WriteString("T")
+	}
+	if hours := d / time.Hour; hours > 0 {
+		fmt.Fprintf(buf, "%dH", hours)
+		d -= hours * time.Hour
+	}
+	if minutes := d / time.Minute; minutes > 0 {
+		fmt.Fprintf(buf, "%dM", minutes)
+		d -= minutes * time.Minute
+	}
+	if d > 0 {
+		buf.WriteString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "S")
+	}
+
+	return buf.String()
+}
+
+// stringInput returns the string given as the value of a scalar.
+func stringInput(v interface{}, name string) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be given as a string, got %s", name, describe(v))
+	}
+
+	return s, nil
+}
+
+// describe returns a description of a value decoded from JSON for use
+// in error messages.
+func describe(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case json.Number:
+		return "number " + v.String()
+	case float64:
+		return "number " + strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return "boolean " + strconv.FormatBool(v)
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	}
+
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,293 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+
+	"dylanmackenzie.com/graphql/ast"
+)
+
+// coerceArguments coerces the arguments given to a field to the types
+// of its argument declarations, applying the declared defaults. Covers
+// section 5.3 of Validation.
+func coerceArguments(decls ast.ArgumentDeclarations, args ast.Arguments, ctx *executionContext) resultMap {
+	out := make(resultMap, len(decls))
+
+	found := make(map[string]bool, len(args))
+	for _, arg := range args {
+		if found[arg.Key] {
+			ctx.addErrorf("Argument '%s' given more than once", arg.Key)
+		}
+		found[arg.Key] = true
+
+		if _, ok := findArgumentDeclaration(decls, arg.Key); !ok {
+			ctx.addErrorf("Unknown argument '%s'", arg.Key)
+		}
+	}
+
+	for _, decl := range decls {
+		value, ok := lookupArgument(args, decl.Key, ctx)
+		if !ok {
+			if decl.Default != nil {
+				value = decl.Default
+			} else if !decl.Type.Nullable() {
+				ctx.addErrorf("Argument '%s' of type '%s' is required", decl.Key, decl.Type.Name())
+				continue
+			} else {
+				continue
+			}
+		}
+
+		v, err := coerceValue(value, decl.Type, ctx)
+		if err != nil {
+			ctx.addErrorf("Argument '%s': %s", decl.Key, err)
+			continue
+		}
+
+		out[decl.Key] = v
+	}
+
+	return out
+}
+
+func findArgumentDeclaration(decls ast.ArgumentDeclarations, key string) (*ast.ArgumentDeclaration, bool) {
+	for i, decl := range decls {
+		if decl.Key == key {
+			return &decls[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// lookupArgument finds the value given to the named argument. An
+// argument whose value is a variable which was not provided is treated
+// as if it had not been given.
+func lookupArgument(args ast.Arguments, key string, ctx *executionContext) (ast.Value, bool) {
+	for _, arg := range args {
+		if arg.Key != key {
+			continue
+		}
+
+		if name, ok := arg.Value.(ast.VariableValue); ok {
+			v, ok := ctx.Variables[string(name)]
+			return v, ok
+		}
+
+		return arg.Value, true
+	}
+
+	return nil, false
+}
+
+// coerceValue converts a value to the Go representation of the given
+// input type, substituting variables. These are the values seen by
+// resolvers:
+//
+//	Int            int
+//	Float          float64
+//	String, ID     string
+//	Boolean        bool
+//	Enum           the Go value bound by AddEnum, or otherwise a string,
+//	               the name of the enum value
+//	List           []interface{}
+//	Input Object   map[string]interface{}, keyed by field name
+//
+// Null values, which can only come from omitted variables, are
+// represented by nil.
+func coerceValue(v ast.Value, desc ast.TypeDescriptor, ctx *executionContext) (interface{}, error) {
+	if name, ok := v.(ast.VariableValue); ok {
+		v, ok = ctx.Variables[string(name)]
+		if !ok {
+			if !desc.Nullable() {
+				return nil, fmt.Errorf("Variable '$%s' of non-nullable type '%s' was not provided", name, desc.Name())
+			}
+			return nil, nil
+		}
+	}
+
+	// Variables from the request have already been coerced to their
+	// declared type
+	if cv, ok := v.(coercedVariable); ok {
+		return cv.useAs(desc)
+	}
+
+	switch t := desc.(type) {
+	case *ast.ListType:
+		// A single value is coerced to a list of one item
+		list, ok := v.(ast.ListValue)
+		if !ok {
+			list = ast.ListValue{v}
+		}
+
+		out := make([]interface{}, len(list))
+		for i, item := range list {
+			var err error
+			if out[i], err = coerceValue(item, t.OfType, ctx); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case *ast.InputObjectType:
+		return coerceInputObject(v, anonymousFields(t), ctx)
+
+	case *ast.BaseType:
+		def, ok := ctx.Schema.types[t.Name()]
+		if !ok {
+			return nil, fmt.Errorf("Type '%s' not found in schema", t.Name())
+		}
+
+		if obj, ok := def.(*ast.InputObjectDefinition); ok {
+			return coerceInputObject(v, obj.Fields, ctx)
+		}
+		return coerceLeafValue(v, def, ctx)
+	}
+
+	return nil, errors.New("Invalid input type")
+}
+
+// coerceInputObject coerces an object value to the given fields. A
+// field which is omitted takes its default value, and is required if it
+// has none and its type is not nullable.
+func coerceInputObject(v ast.Value, fields ast.ArgumentDeclarations, ctx *executionContext) (interface{}, error) {
+	obj, ok := v.(ast.ObjectValue)
+	if !ok {
+		return nil, fmt.Errorf("Expected an input object, got %s", describeValue(v))
+	}
+
+	for key := range obj {
+		if _, ok := findArgumentDeclaration(fields, key); !ok {
+			return nil, fmt.Errorf("Unknown input object field '%s'", key)
+		}
+	}
+
+	out := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		item, ok := obj[field.Key]
+
+		// A field given a variable which was not provided is treated
+		// as if it had been omitted.
+		if name, isVar := item.(ast.VariableValue); ok && isVar {
+			_, ok = ctx.Variables[string(name)]
+		}
+
+		if !ok {
+			if field.Default != nil {
+				item = field.Default
+			} else if !field.Type.Nullable() {
+				return nil, fmt.Errorf("Input object field '%s' of type '%s' is required", field.Key, field.Type.Name())
+			} else {
+				continue
+			}
+		}
+
+		coerced, err := coerceValue(item, field.Type, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Input object field '%s': %s", field.Key, err)
+		}
+		out[field.Key] = coerced
+	}
+
+	return out, nil
+}
+
+// anonymousFields returns the fields of an input object type declared
+// inline, which have no default values.
+func anonymousFields(t *ast.InputObjectType) ast.ArgumentDeclarations {
+	fields := make(ast.ArgumentDeclarations, 0, len(t.Fields))
+	for key, desc := range t.Fields {
+		fields = append(fields, ast.ArgumentDeclaration{Key: key, Type: desc})
+	}
+
+	return fields
+}
+
+// coerceLeafValue coerces a value to a scalar or enum type.
+func coerceLeafValue(v ast.Value, def ast.TypeDefinition, ctx *executionContext) (interface{}, error) {
+	switch t := def.(type) {
+	case *ast.EnumDefinition:
+		name, ok := v.(ast.EnumValue)
+		if !ok {
+			return nil, fmt.Errorf("Expected a value of enum '%s', got %s", t.Name, describeValue(v))
+		}
+		return ctx.Schema.parseEnum(string(name), t)
+
+	case *ast.ScalarDefinition:
+		if s, ok := ctx.Schema.scalar(t.Name); ok {
+			out, err := s.parseLiteral(v, ctx)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid value %s for '%s': %s", describeValue(v), t.Name, err)
+			}
+			return out, nil
+		}
+		return coerceScalar(v, t)
+	}
+
+	return nil, fmt.Errorf("Type '%s' is not an input type", def.TypeName())
+}
+
+func coerceScalar(v ast.Value, def *ast.ScalarDefinition) (interface{}, error) {
+	switch def.Kind {
+	case reflect.Int:
+		if i, ok := v.(ast.IntValue); ok {
+			if i < math.MinInt32 || i > math.MaxInt32 {
+				return nil, fmt.Errorf("Value %d does not fit in a 32-bit '%s'", i, def.Name)
+			}
+			return int(i), nil
+		}
+
+	case reflect.Float64:
+		switch f := v.(type) {
+		case ast.FloatValue:
+			return float64(f), nil
+		case ast.IntValue:
+			return float64(f), nil
+		}
+
+	case reflect.String:
+		switch s := v.(type) {
+		case ast.StringValue:
+			return s.Unescape()
+		case ast.IntValue:
+			// IDs may be given as integers
+			if def.Name == "ID" {
+				return fmt.Sprint(int(s)), nil
+			}
+		}
+
+	case reflect.Bool:
+		if b, ok := v.(ast.BooleanValue); ok {
+			return bool(b), nil
+		}
+	}
+
+	return nil, fmt.Errorf("Expected a value of type '%s', got %s", def.Name, describeValue(v))
+}
+
+// describeValue returns a description of an AST value for use in error
+// messages.
+func describeValue(v ast.Value) string {
+	switch v := v.(type) {
+	case ast.IntValue:
+		return fmt.Sprintf("Int %d", int(v))
+	case ast.FloatValue:
+		return fmt.Sprintf("Float %g", float64(v))
+	case ast.StringValue:
+		return fmt.Sprintf("String \"%s\"", string(v))
+	case ast.BooleanValue:
+		return fmt.Sprintf("Boolean %t", bool(v))
+	case ast.EnumValue:
+		return fmt.Sprintf("enum value %s", string(v))
+	case ast.ListValue:
+		return "a list"
+	case ast.ObjectValue:
+		return "an input object"
+	case nil:
+		return "null"
+	}
+
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,205 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"dylanmackenzie.com/graphql/ast"
+)
+
+var coerceSchema = `
+enum Episode { NEWHOPE, EMPIRE, JEDI }
+
+input Location {
+  lat: Float!
+  lng: Float!
+}
+
+input Criteria {
+  name: String!
+  episodes: [Episode] = [NEWHOPE]
+  near: Location
+  stops: [Location!]
+}
+
+type Result {
+  value: String
+}
+
+type Query {
+  search(text: String!, limit: Int, id: ID, ids: [ID], scores: [[Float]], episode: Episode, filter: {name: String!, age: Int}, criteria: Criteria): Result
+}
+`
+
+type coerceTest struct {
+	args   string
+	expect map[string]interface{} // nil if the arguments are invalid
+}
+
+var coerceTests = map[string]coerceTest{
+	"Required": {`text: "luke"`, map[string]interface{}{"text": "luke"}},
+	"Scalars": {`text: "luke", limit: 10, id: 1000`,
+		map[string]interface{}{"text": "luke", "limit": 10, "id": "1000"}},
+	"EscapedString": {`text: "a\nb \"c\" \u00e9"`, map[string]interface{}{"text": "a\nb \"c\" \u00e9"}},
+	"Lists": {`text: "", ids: ["1", 2], scores: [[1, 2.5], [3]]`,
+		map[string]interface{}{
+			"text":   "",
+			"ids":    []interface{}{"1", "2"},
+			"scores": []interface{}{[]interface{}{1.0, 2.5}, []interface{}{3.0}},
+		}},
+	"SingleItemList": {`text: "", ids: 5`,
+		map[string]interface{}{"text": "", "ids": []interface{}{"5"}}},
+	"Enum": {`text: "", episode: JEDI`,
+		map[string]interface{}{"text": "", "episode": "JEDI"}},
+	"InputObject": {`text: "", filter: {name: "luke", age: 19}`,
+		map[string]interface{}{"text": "", "filter": map[string]interface{}{"name": "luke", "age": 19}}},
+	"NamedInputObject": {`text: "", criteria: {name: "luke"}`,
+		map[string]interface{}{"text": "", "criteria": map[string]interface{}{
+			"name": "luke", "episodes": []interface{}{"NEWHOPE"},
+		}}},
+	"NestedInputObjects": {`text: "", criteria: {name: "", episodes: JEDI, near: {lat: 1, lng: 2.5}, stops: [{lat: 0, lng: 0}]}`,
+		map[string]interface{}{"text": "", "criteria": map[string]interface{}{
+			"name":     "",
+			"episodes": []interface{}{"JEDI"},
+			"near":     map[string]interface{}{"lat": 1.0, "lng": 2.5},
+			"stops":    []interface{}{map[string]interface{}{"lat": 0.0, "lng": 0.0}},
+		}}},
+	"InputFieldVariables": {`text: "", criteria: {name: $text, episodes: $missing}`,
+		map[string]interface{}{"text": "", "criteria": map[string]interface{}{
+			"name": "luke", "episodes": []interface{}{"NEWHOPE"},
+		}}},
+	"Variables": {`text: $text, limit: $missing, ids: [$limit]`,
+		map[string]interface{}{"text": "luke", "ids": []interface{}{"10"}}},
+
+	"MissingRequired":    {`limit: 10`, nil},
+	"MissingVariable":    {`text: $missing`, nil},
+	"Unknown":            {`text: "", page: 2`, nil},
+	"Duplicate":          {`text: "", text: ""`, nil},
+	"WrongType":          {`text: 10`, nil},
+	"InvalidEscape":      {`text: "\q"`, nil},
+	"IntOverflow":        {`text: "", limit: 3000000000`, nil},
+	"FloatForInt":        {`text: "", limit: 1.5`, nil},
+	"UnknownEnum":        {`text: "", episode: CLONES`, nil},
+	"StringForEnum":      {`text: "", episode: "JEDI"`, nil},
+	"WrongListItem":      {`text: "", scores: [["1"]]`, nil},
+	"MissingInputField":  {`text: "", filter: {age: 19}`, nil},
+	"UnknownInputField":  {`text: "", filter: {name: "", height: 2}`, nil},
+	"ScalarForInputType": {`text: "", filter: 5`, nil},
+	"MissingNestedField": {`text: "", criteria: {name: "", near: {lat: 1}}`, nil},
+	"WrongNestedField":   {`text: "", criteria: {name: "", stops: [{lat: 1, lng: "2"}]}`, nil},
+	"NullNestedItem":     {`text: "", criteria: {name: "", stops: [$missing]}`, nil},
+}
+
+func TestCoerceArguments(t *testing.T) {
+	sch := newTestSchema(t, coerceSchema, "Query", "")
+	sch.Finalize()
+
+	decl, _ := sch.QueryRoot.Field("search")
+
+	for name, test := range coerceTests {
+		doc, err := ast.FromReader(strings.NewReader("{ search(" + test.args + ") { value } }"))
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		field := doc.Definitions[0].(*ast.OperationDefinition).SelectionSet[0].(*ast.Field)
+
+		ctx := NewContext(sch)
+		ctx.lazyPanic = true
+		ctx.Variables["text"] = ast.StringValue("luke")
+		ctx.Variables["limit"] = ast.IntValue(10)
+
+		args := coerceArguments(decl.Arguments, field.Arguments, ctx)
+		if test.expect == nil {
+			if len(ctx.Errors) == 0 {
+				t.Errorf("%s: Expected an error, got %v", name, args)
+			}
+			continue
+		}
+
+		if err := ctx.Errors.Err(); err != nil {
+			t.Errorf("%s: %s", name, err)
+		} else if !reflect.DeepEqual(map[string]interface{}(args), test.expect) {
+			t.Errorf("%s: Expected %v, got %v", name, test.expect, args)
+		}
+	}
+}
+
+func TestCoerceArgumentDefaults(t *testing.T) {
+	decls := ast.ArgumentDeclarations{
+		{Key: "limit", Type: &ast.ListType{OfType: &ast.BaseType{}}, Default: ast.ListValue{}},
+	}
+
+	ctx := NewContext(New())
+	args := coerceArguments(decls, nil, ctx)
+	if v, ok := args["limit"]; !ok || len(v.([]interface{})) != 0 {
+		t.Errorf("Expected the default value to be applied, got %v", args)
+	}
+}
+
+func TestArgsSliceGetters(t *testing.T) {
+	args := resultMap{"ids": []interface{}{"1", "2"}, "mixed": []interface{}{"1", 2}}
+
+	if ids, ok := args.GetAsStrings("ids"); !ok || len(ids) != 2 || ids[1] != "2" {
+		t.Errorf("Expected [1 2], got %v", ids)
+	}
+
+	if _, ok := args.GetAsStrings("mixed"); ok {
+		t.Error("Expected list of mixed types not to convert to []string")
+	}
+}
+
+func TestInputObjectArgument(t *testing.T) {
+	sch := newTestSchema(t, coerceSchema, "Query", "")
+	sch.AddResolveFunc("Result", func(r *ResponseNode) {
+		criteria, _ := r.Args.GetAsMap("criteria")
+		near, _ := resultMap(criteria).GetAsMap("near")
+		stops, _ := resultMap(criteria).GetAsMaps("stops")
+		r.Set("value", fmt.Sprintf("%s %v %d", criteria["name"], near["lat"], len(stops)))
+	})
+	sch.Finalize()
+
+	doc, err := ast.FromReader(strings.NewReader(`{
+		search(text: "", criteria: {name: "luke", near: {lat: 1, lng: 2}, stops: [{lat: 0, lng: 0}]}) { value }
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := Execute(context.Background(), sch, &doc, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, _ := ctx.Response.MarshalJSON()
+	if expect := `{"search":{"value":"luke 1 1"}}`; string(actual) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, actual)
+	}
+}
+
+func TestInputObjectDefinitions(t *testing.T) {
+	invalid := map[string]string{
+		"ObjectField": `
+			type Point { x: Int }
+			input Search { at: Point }
+			type Query { search(q: Search): Int }`,
+		"InputOutput": `
+			input Search { text: String }
+			type Query { search: Search }`,
+		"InvalidDefault": `
+			input Search { limit: Int = "ten" }
+			type Query { search(q: Search): Int }`,
+		"ObjectArgument": `
+			type Point { x: Int }
+			type Query { search(at: Point): Int }`,
+	}
+
+	for name, doc := range invalid {
+		func() {
+			defer shouldPanic(name, t)
+			newTestSchema(t, doc, "Query", "").Finalize()
+		}()
+	}
+}
@@ -2,9 +2,15 @@ package schema
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"sync"
 
 	"dylanmackenzie.com/graphql/ast"
+	"dylanmackenzie.com/graphql/dataloader"
 )
 
 type errorList []error
@@ -28,7 +34,11 @@ func (e errorList) Err() error {
 	return e
 }
 
-type context struct {
+type executionContext struct {
+	// The context of the request. Once it is done, no new fields are
+	// resolved.
+	Context context.Context
+
 	// Schema
 
 	Schema *Schema               // A reference to the graphql type system.
@@ -45,20 +55,38 @@ type context struct {
 	// The root response node
 	Response *ResponseNode
 
+	// Schedules the execution of the fields in the request.
+	executor Executor
+
+	// The loaders of the request. Every field counts as an active task
+	// of the group from the moment it is scheduled until its resolver
+	// has returned and its sub-fields have been scheduled.
+	loaders *dataloader.Group
+
 	// Error Handling
 
-	Errors errorList // The list of errors encountered while processing the request.
+	Errors errorList  // The list of errors encountered while processing the request.
+	mu     sync.Mutex // Guards Errors, which may be appended to by any field.
+
+	cancelOnce sync.Once // Ensures the error of a done Context is only recorded once.
 
 	// A boolean indicating whether the execution should panic
 	// immediately after the first error it encounters or continue
 	// parsing the entire document. Should be set to true only for
 	// development purposes.
-	lazyPanic       bool
+	lazyPanic bool
+
+	// Whether the fields of the root selection set should be executed
+	// one after another instead of in parallel. Set for mutations.
 	serialExecution bool
+
+	// Whether the introspection fields of the query root are hidden.
+	noIntrospection bool
 }
 
-func NewContext(sch *Schema) *context {
-	return &context{
+func NewContext(sch *Schema) *executionContext {
+	return &executionContext{
+		Context:   context.Background(),
 		Schema:    sch,
 		Variables: make(map[string]ast.Value),
 		Fragments: make(map[string]*ast.FragmentDefinition),
@@ -66,21 +94,42 @@ func NewContext(sch *Schema) *context {
 	}
 }
 
-func (ctx *context) addErrorf(s string, v ...interface{}) {
+func (ctx *executionContext) addErrorf(s string, v ...interface{}) {
 	ctx.addError(fmt.Errorf(s, v...))
 
 }
 
-func (ctx *context) addError(err error) {
-	ctx.Errors = append(ctx.Errors, err)
+func (ctx *executionContext) addError(err error) {
+	ctx.appendError(err)
 	if !ctx.lazyPanic {
 		panic(ctx.Errors)
 	}
 }
 
+// appendError records an error without aborting the execution.
+func (ctx *executionContext) appendError(err error) {
+	ctx.mu.Lock()
+	ctx.Errors = append(ctx.Errors, err)
+	ctx.mu.Unlock()
+}
+
+// cancelled reports whether the context of the request is done. The
+// first time it is, the reason is recorded as an error, but the
+// execution is not aborted so that a partial response can still be
+// returned.
+func (ctx *executionContext) cancelled() bool {
+	err := ctx.Context.Err()
+	if err == nil {
+		return false
+	}
+
+	ctx.cancelOnce.Do(func() { ctx.appendError(err) })
+	return true
+}
+
 // getOperationRootType finds the appropriate root in the schema for the
 // active GraphQL operation and stores it in ctx.Root
-func (ctx *context) getOperationRootType() {
+func (ctx *executionContext) getOperationRootType() {
 	switch ctx.Operation.OpType {
 	case ast.QUERY:
 		ctx.Root = ctx.Schema.QueryRoot
@@ -100,7 +149,7 @@ func (ctx *context) getOperationRootType() {
 // active operation of the given document, storing it in ctx, while
 // ensuring the uniqueness of all definitions. Covers sections 5.1 and
 // 5.4.1 of Validation.
-func (ctx *context) processDefinitions(doc *ast.Document, active string) {
+func (ctx *executionContext) processDefinitions(doc *ast.Document, active string) {
 	foundOps := make(map[string]bool, len(doc.Definitions))
 	opCount := 0
 	for _, t := range doc.Definitions {
@@ -141,7 +190,32 @@ func (ctx *context) processDefinitions(doc *ast.Document, active string) {
 	}
 }
 
-// ParseVariablesFromJSON parses a set of GraphQL variables from a JSON string
-func (ctx *context) ParseVariablesFromJSON(json string) error {
+// ParseVariablesFromJSON decodes the variables of a request from a JSON
+// object and coerces them to the types declared by the active
+// operation.
+func (ctx *executionContext) ParseVariablesFromJSON(data string) error {
+	vars := make(map[string]interface{})
+	if data != "" {
+		if err := unmarshalJSON([]byte(data), &vars); err != nil {
+			return fmt.Errorf("Variables must be a JSON object: %s", err)
+		}
+	}
+
+	return ctx.coerceVariables(vars).Err()
+}
+
+// unmarshalJSON is like json.Unmarshal, but decodes numbers as
+// json.Number, so that integers too large for a float64, such as IDs,
+// keep every digit.
+func unmarshalJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("Unexpected data after JSON value")
+	}
 	return nil
 }
@@ -0,0 +1,201 @@
+package schema
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DecodeArgs stores the arguments of the field resolved by r in the
+// struct pointed to by v.
+//
+// Each argument is stored in the exported field whose `graphql` tag
+// gives its name, or otherwise in the field whose name matches it
+// regardless of case. A tag of "-" excludes a field. Arguments with no
+// matching field are ignored, as are fields with no matching argument,
+// so that v may be given default values beforehand.
+//
+// Input objects are decoded into structs or maps with string keys, and
+// lists into slices. Enum values are decoded into the type of the Go
+// values bound to the enum by AddEnum, or otherwise into strings or any
+// type implementing encoding.TextUnmarshaler. A null value leaves a
+// pointer nil and any other type at its zero value.
+func (r *ResponseNode) DecodeArgs(v interface{}) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("DecodeArgs requires a pointer to a struct, got %T", v)
+	}
+
+	return decodeObject(map[string]interface{}(r.Args), val.Elem(), "")
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// decodeValue stores a coerced argument value in out. path names the
+// value in error messages.
+func decodeValue(in interface{}, out reflect.Value, path string) error {
+	if in == nil {
+		out.Set(reflect.Zero(out.Type()))
+		return nil
+	}
+
+	if out.Kind() == reflect.Ptr {
+		if out.IsNil() {
+			out.Set(reflect.New(out.Type().Elem()))
+		}
+		return decodeValue(in, out.Elem(), path)
+	}
+
+	if s, ok := in.(string); ok && reflect.PtrTo(out.Type()).Implements(textUnmarshalerType) {
+		if err := out.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("Argument '%s': %s", path, err)
+		}
+		return nil
+	}
+
+	// Values such as those bound to enums by AddEnum are stored as
+	// they are in fields of their own type.
+	if reflect.TypeOf(in).AssignableTo(out.Type()) {
+		out.Set(reflect.ValueOf(in))
+		return nil
+	}
+
+	switch out.Kind() {
+	case reflect.Interface:
+		return decodeError(in, out, path)
+
+	case reflect.Struct:
+		obj, ok := in.(map[string]interface{})
+		if !ok {
+			return decodeError(in, out, path)
+		}
+		return decodeObject(obj, out, path+".")
+
+	case reflect.Map:
+		obj, ok := in.(map[string]interface{})
+		if !ok || out.Type().Key().Kind() != reflect.String {
+			return decodeError(in, out, path)
+		}
+
+		out.Set(reflect.MakeMapWithSize(out.Type(), len(obj)))
+		for key, item := range obj {
+			elem := reflect.New(out.Type().Elem()).Elem()
+			if err := decodeValue(item, elem, path+"."+key); err != nil {
+				return err
+			}
+			out.SetMapIndex(reflect.ValueOf(key).Convert(out.Type().Key()), elem)
+		}
+		return nil
+
+	case reflect.Slice:
+		list, ok := in.([]interface{})
+		if !ok {
+			return decodeError(in, out, path)
+		}
+
+		out.Set(reflect.MakeSlice(out.Type(), len(list), len(list)))
+		for i, item := range list {
+			if err := decodeValue(item, out.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.String:
+		s, ok := in.(string)
+		if !ok {
+			return decodeError(in, out, path)
+		}
+		out.SetString(s)
+		return nil
+
+	case reflect.Bool:
+		b, ok := in.(bool)
+		if !ok {
+			return decodeError(in, out, path)
+		}
+		out.SetBool(b)
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := in.(int)
+		if !ok || out.OverflowInt(int64(i)) {
+			return decodeError(in, out, path)
+		}
+		out.SetInt(int64(i))
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, ok := in.(int)
+		if !ok || i < 0 || out.OverflowUint(uint64(i)) {
+			return decodeError(in, out, path)
+		}
+		out.SetUint(uint64(i))
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		f, ok := in.(float64)
+		if !ok {
+			return decodeError(in, out, path)
+		}
+		out.SetFloat(f)
+		return nil
+	}
+
+	return decodeError(in, out, path)
+}
+
+// decodeObject stores the fields of an input object, or the arguments
+// of a field, in the matching fields of a struct. prefix is prepended
+// to the name of each field in error messages.
+func decodeObject(in map[string]interface{}, out reflect.Value, prefix string) error {
+	t := out.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Tag.Get("graphql")
+		if name == "-" {
+			continue
+		}
+
+		key, ok := matchKey(in, name, field.Name)
+		if !ok {
+			continue
+		}
+
+		if err := decodeValue(in[key], out.Field(i), prefix+key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// matchKey finds the key of an object which is stored in a struct field
+// with the given tag and name.
+func matchKey(in map[string]interface{}, tag, name string) (string, bool) {
+	if tag != "" {
+		_, ok := in[tag]
+		return tag, ok
+	}
+
+	if _, ok := in[name]; ok {
+		return name, true
+	}
+
+	for key := range in {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+func decodeError(in interface{}, out reflect.Value, path string) error {
+	return fmt.Errorf("Argument '%s': cannot decode %T value %v into %s", path, in, in, out.Type())
+}
@@ -0,0 +1,95 @@
+package schema
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// episode decodes the names of the values of the Episode enum.
+type episode int
+
+func (e *episode) UnmarshalText(text []byte) error {
+	for i, name := range []string{"NEWHOPE", "EMPIRE", "JEDI"} {
+		if string(text) == name {
+			*e = episode(i)
+			return nil
+		}
+	}
+
+	return errors.New("Unknown episode " + string(text))
+}
+
+type location struct {
+	Lat, Lng float64
+}
+
+type searchArgs struct {
+	Text    string
+	Limit   *int
+	IDs     []string `graphql:"ids"`
+	Episode episode
+	Filter  map[string]interface{}
+	Near    *location `graphql:"near"`
+	Stops   []location
+	Ignored string `graphql:"-"`
+}
+
+func TestDecodeArgs(t *testing.T) {
+	r := &ResponseNode{Args: resultMap{
+		"text":    "luke",
+		"ids":     []interface{}{"1", "2"},
+		"episode": "JEDI",
+		"filter":  map[string]interface{}{"name": "luke"},
+		"near":    map[string]interface{}{"lat": 1.5, "lng": 2.0},
+		"stops":   []interface{}{map[string]interface{}{"lat": 0.0, "lng": 1.0}},
+		"ignored": "value",
+	}}
+
+	var args searchArgs
+	if err := r.DecodeArgs(&args); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := searchArgs{
+		Text:    "luke",
+		IDs:     []string{"1", "2"},
+		Episode: 2,
+		Filter:  map[string]interface{}{"name": "luke"},
+		Near:    &location{1.5, 2},
+		Stops:   []location{{0, 1}},
+	}
+	if !reflect.DeepEqual(args, expect) {
+		t.Errorf("Expected %+v, got %+v", expect, args)
+	}
+
+	r.Args = resultMap{"limit": 10, "near": nil}
+	if err := r.DecodeArgs(&args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Limit == nil || *args.Limit != 10 || args.Near != nil || args.Text != "luke" {
+		t.Errorf("Expected only limit and near to change, got %+v", args)
+	}
+}
+
+func TestDecodeArgsErrors(t *testing.T) {
+	invalid := []resultMap{
+		{"text": 5},
+		{"limit": "ten"},
+		{"episode": "CLONES"},
+		{"stops": []interface{}{map[string]interface{}{"lat": "north"}}},
+		{"near": []interface{}{}},
+	}
+
+	for _, args := range invalid {
+		r := &ResponseNode{Args: args}
+		if err := r.DecodeArgs(&searchArgs{}); err == nil {
+			t.Errorf("Expected an error decoding %v", args)
+		}
+	}
+
+	r := &ResponseNode{Args: resultMap{}}
+	if err := r.DecodeArgs(searchArgs{}); err == nil {
+		t.Error("Expected an error decoding into a non-pointer")
+	}
+}
@@ -0,0 +1,174 @@
+package schema
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+
+	"dylanmackenzie.com/graphql/ast"
+)
+
+// An enumBinding maps the values of an enum type to Go values and back.
+type enumBinding struct {
+	values map[string]interface{} // Go value of each enum value
+	names  map[interface{}]string // Enum value of each Go value
+	typ    reflect.Type           // The type shared by every Go value
+}
+
+// AddEnum binds the values of the named enum type, which must already be
+// declared in the schema, to Go values such as the constants of an int
+// or string type:
+//
+//	type Episode int
+//
+//	const (
+//		NewHope Episode = iota
+//		Empire
+//		Jedi
+//	)
+//
+//	sch.AddEnum("Episode", map[string]interface{}{
+//		"NEWHOPE": NewHope,
+//		"EMPIRE":  Empire,
+//		"JEDI":    Jedi,
+//	})
+//
+// Arguments of the enum type are then given to resolvers as the Go value
+// bound to their name, and fields of the enum type must be set to a
+// bound Go value, which is serialized as its name. Every value of the
+// enum must be bound to a distinct Go value, and all of the Go values
+// must have the same integer or string type.
+//
+// Enums which are not bound are given to resolvers, and must be set, as
+// the names of their values.
+func (sch *Schema) AddEnum(name string, values map[string]interface{}) {
+	if !sch.mutable {
+		panic("Attempted to mutate schema after it has been finalized")
+	}
+
+	def, ok := sch.types[name]
+	if !ok {
+		log.Panicf("No type named '%s' found", name)
+	}
+
+	enum, ok := def.(*ast.EnumDefinition)
+	if !ok {
+		log.Panicf("Attempting to bind values to non-enum type '%s'", name)
+	}
+
+	b := &enumBinding{
+		values: make(map[string]interface{}, len(values)),
+		names:  make(map[interface{}]string, len(values)),
+	}
+
+	for key, v := range values {
+		if _, ok := enum.Values[key]; !ok {
+			log.Panicf("Enum '%s' has no value '%s'", name, key)
+		}
+
+		t := reflect.TypeOf(v)
+		switch {
+		case t == nil:
+			log.Panicf("Enum value '%s.%s' is bound to nil", name, key)
+		case b.typ == nil:
+			b.typ = t
+		case t != b.typ:
+			log.Panicf("Enum value '%s.%s' is bound to a %s, not a %s", name, key, t, b.typ)
+		}
+
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.String:
+		default:
+			log.Panicf("Enum value '%s.%s' must be bound to an integer or string, not a %s", name, key, t)
+		}
+
+		if other, ok := b.names[v]; ok {
+			log.Panicf("Enum values '%s.%s' and '%s.%s' are bound to the same value %v", name, other, name, key, v)
+		}
+
+		b.values[key] = v
+		b.names[v] = key
+	}
+
+	for key := range enum.Values {
+		if _, ok := b.values[key]; !ok {
+			log.Panicf("Enum value '%s.%s' is not bound to a Go value", name, key)
+		}
+	}
+
+	sch.enums[name] = b
+}
+
+// enum returns the binding of an enum type, if it has one. sch may be
+// nil.
+func (sch *Schema) enum(name string) (*enumBinding, bool) {
+	if sch == nil {
+		return nil, false
+	}
+
+	b, ok := sch.enums[name]
+	return b, ok
+}
+
+// parseEnum converts the name of a value of an enum to the value seen by
+// resolvers.
+func (sch *Schema) parseEnum(name string, def *ast.EnumDefinition) (interface{}, error) {
+	if _, ok := def.Values[name]; !ok {
+		return nil, fmt.Errorf("Enum '%s' has no value '%s'", def.Name, name)
+	}
+
+	if b, ok := sch.enum(def.Name); ok {
+		return b.values[name], nil
+	}
+	return name, nil
+}
+
+// serializeEnum converts a value set by a resolver for an enum field to
+// the name of the enum value. Values of a bound enum may also be given
+// as a name, or as a value convertible to the bound type.
+func (sch *Schema) serializeEnum(v reflect.Value, def *ast.EnumDefinition) (interface{}, error) {
+	b, ok := sch.enum(def.Name)
+	if !ok {
+		if v.Kind() == reflect.String {
+			if _, ok := def.Values[v.String()]; ok {
+				return v.String(), nil
+			}
+			return nil, fmt.Errorf("Enum '%s' has no value '%s'", def.Name, v.String())
+		}
+		return nil, fmt.Errorf("Value %v of type %s is not of type '%s'", v.Interface(), v.Type(), def.Name)
+	}
+
+	if v.Type() != b.typ && v.Type().ConvertibleTo(b.typ) && sameKind(v.Kind(), b.typ.Kind()) {
+		// Values which do not survive the conversion are left as they
+		// are, and rejected below.
+		if c := v.Convert(b.typ); c.Convert(v.Type()).Interface() == v.Interface() {
+			v = c
+		}
+	}
+	if v.Type() == b.typ {
+		if name, ok := b.names[v.Interface()]; ok {
+			return name, nil
+		}
+	}
+
+	if v.Kind() == reflect.String {
+		if _, ok := def.Values[v.String()]; ok {
+			return v.String(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("Value %v of type %s is not a value of enum '%s'", v.Interface(), v.Type(), def.Name)
+}
+
+// sameKind reports whether two kinds are both integers or both strings,
+// so that a value of one may be converted to the other without changing
+// its meaning.
+func sameKind(a, b reflect.Kind) bool {
+	isInt := func(k reflect.Kind) bool {
+		return k >= reflect.Int && k <= reflect.Uint64
+	}
+
+	return a == b || isInt(a) && isInt(b)
+}
@@ -0,0 +1,147 @@
+package schema
+
+import "testing"
+
+var enumSchema = `
+enum Episode { NEWHOPE, EMPIRE, JEDI }
+
+type Film {
+  episode: Episode
+  episodes: [Episode!]
+}
+
+type Query {
+  film(episode: Episode = NEWHOPE, also: [Episode!]): Film
+}
+`
+
+type episodeNumber int
+
+const (
+	newHope episodeNumber = iota + 4
+	empire
+	jedi
+)
+
+var episodes = map[string]interface{}{
+	"NEWHOPE": newHope,
+	"EMPIRE":  empire,
+	"JEDI":    jedi,
+}
+
+func TestEnumBinding(t *testing.T) {
+	sch := newTestSchema(t, enumSchema, "Query", "")
+	sch.AddEnum("Episode", episodes)
+	sch.AddResolveFunc("Film", func(r *ResponseNode) {
+		var args struct {
+			Episode episodeNumber
+			Also    []episodeNumber
+		}
+		if err := r.DecodeArgs(&args); err != nil {
+			t.Error(err)
+		}
+
+		r.Set("episode", args.Episode)
+		r.Set("episodes", append(args.Also, 5))
+	})
+	sch.Finalize()
+
+	res, err := executeQuery(t, sch, `{ a: film(episode: EMPIRE, also: [JEDI]) { episode episodes } b: film { episode } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := res.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expect := `{"a":{"episode":"EMPIRE","episodes":["JEDI","EMPIRE"]},"b":{"episode":"NEWHOPE"}}`; string(actual) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, actual)
+	}
+}
+
+func TestEnumBindingOutput(t *testing.T) {
+	returnOfTheJedi := jedi
+	tests := []struct {
+		value  interface{}
+		expect string // "" if the value is invalid
+	}{
+		{jedi, "JEDI"},
+		{&returnOfTheJedi, "JEDI"},
+		{6, "JEDI"},
+		{uint8(4), "NEWHOPE"},
+		{"EMPIRE", "EMPIRE"},
+		{episodeNumber(7), ""},
+		{1<<32 + 4, ""},
+		{"PHANTOM", ""},
+		{4.0, ""},
+	}
+
+	for _, test := range tests {
+		sch := newTestSchema(t, enumSchema, "Query", "")
+		sch.AddEnum("Episode", episodes)
+		sch.AddResolveFunc("Film", func(r *ResponseNode) {
+			r.Set("episode", test.value)
+		})
+		sch.Finalize()
+
+		res, err := executeQuery(t, sch, `{ film { episode } }`)
+
+		actual, _ := res.MarshalJSON()
+		if test.expect == "" {
+			if err == nil {
+				t.Errorf("%v: Expected an error, got '%s'", test.value, actual)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%v: %s", test.value, err)
+		} else if expect := `{"film":{"episode":"` + test.expect + `"}}`; string(actual) != expect {
+			t.Errorf("%v: Expected '%s', got '%s'", test.value, expect, actual)
+		}
+	}
+}
+
+func TestUnboundEnum(t *testing.T) {
+	sch := newTestSchema(t, enumSchema, "Query", "")
+	sch.AddResolveFunc("Film", func(r *ResponseNode) {
+		v, _ := r.Args.Get("episode")
+		if v != "JEDI" {
+			t.Errorf("Expected the name of the value, got %#v", v)
+		}
+		r.Set("episode", v)
+	})
+	sch.Finalize()
+
+	res, err := executeQuery(t, sch, `{ film(episode: JEDI) { episode } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual, _ := res.MarshalJSON(); string(actual) != `{"film":{"episode":"JEDI"}}` {
+		t.Errorf("Unexpected response '%s'", actual)
+	}
+}
+
+func TestAddEnumInvalid(t *testing.T) {
+	for _, values := range []map[string]interface{}{
+		{"NEWHOPE": newHope, "EMPIRE": empire},
+		{"NEWHOPE": newHope, "EMPIRE": empire, "JEDI": jedi, "PHANTOM": episodeNumber(1)},
+		{"NEWHOPE": newHope, "EMPIRE": empire, "JEDI": 6},
+		{"NEWHOPE": newHope, "EMPIRE": empire, "JEDI": newHope},
+		{"NEWHOPE": 1.0, "EMPIRE": 2.0, "JEDI": 3.0},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%v: Expected a panic", values)
+				}
+			}()
+
+			sch := newTestSchema(t, enumSchema, "Query", "")
+			sch.AddEnum("Episode", values)
+		}()
+	}
+}
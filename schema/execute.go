@@ -1,19 +1,87 @@
 package schema
 
-import "dylanmackenzie.com/graphql/ast"
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"dylanmackenzie.com/graphql/ast"
+	"dylanmackenzie.com/graphql/dataloader"
+)
 
 // Build an execution context from a schema, graphql document, and a
 // string naming the active definition in the document (which must be the empty
 // string if the client did not specify an operation name).
-func Execute(sch *Schema, doc *ast.Document, active string) (ctx *context, err error) {
+//
+// Once c is done, no new fields are resolved and the fields which were
+// not yet resolved are set to null. The error of c is then returned
+// alongside the partial response. An operation which is invalid for the
+// schema, such as one selecting a field which does not exist or giving
+// an argument of the wrong type, has no response at all. If c is nil,
+// context.Background() is used.
+func Execute(c context.Context, sch *Schema, doc *ast.Document, active string) (*executionContext, error) {
+	return ExecuteWith(c, nil, sch, doc, active)
+}
+
+// ExecuteWith is like Execute, but schedules the fields of the request
+// using the given Executor instead of the one provided by the schema.
+// If exec is nil, the schema's Executor is used.
+func ExecuteWith(c context.Context, exec Executor, sch *Schema, doc *ast.Document, active string) (*executionContext, error) {
+	return executeRequest(c, sch, doc, active, executeOptions{executor: exec})
+}
+
+// executeOptions configures the execution of a single request.
+type executeOptions struct {
+	executor  Executor               // If nil, the schema's Executor is used.
+	variables map[string]interface{} // As decoded from JSON.
+
+	// Whether the __schema and __type fields of the query root are
+	// hidden.
+	noIntrospection bool
+}
+
+// executeRequest executes the active operation of a document.
+func executeRequest(c context.Context, sch *Schema, doc *ast.Document, active string, opts executeOptions) (ctx *executionContext, err error) {
+	if c == nil {
+		c = context.Background()
+	}
+
 	// Construct a new execution context
 	// the server.
 	ctx = NewContext(sch)
+	ctx.Context = c
+	ctx.noIntrospection = opts.noIntrospection
+	ctx.executor = opts.executor
+	if ctx.executor == nil {
+		ctx.executor = sch.executor()
+	}
+	ctx.loaders = dataloader.NewGroup(c, sch.loaders)
+
+	// Whether the root fields are being resolved and scheduled, during
+	// which the root is an active task of the request's loaders.
+	expanding := false
 
-	// Call recover() on a panicking execution before it crashes
+	// Call recover() on a panicking execution before it crashes. Errors
+	// raised through addError have already been recorded.
 	defer func() {
 		if r := recover(); r != nil {
-			ctx.addErrorf("%v", r)
+			errs, invalid := r.(errorList)
+			if !invalid {
+				ctx.appendError(fmt.Errorf("%v", r))
+			}
+
+			// The root fields scheduled before the panic must finish
+			// before the response may be read. A root selection set
+			// which is invalid fails the whole request, and so the
+			// response and the errors of those fields are discarded.
+			if expanding {
+				ctx.loaders.Done()
+				ctx.Response.wg.Wait()
+				if invalid {
+					ctx.Response = nil
+					ctx.Errors = errs
+				}
+			}
 		}
 		err = ctx.Errors.Err()
 	}()
@@ -25,37 +93,239 @@ func Execute(sch *Schema, doc *ast.Document, active string) (ctx *context, err e
 		return
 	}
 
+	// Variables are coerced before anything is executed, so that an
+	// invalid variable fails the whole request.
+	if errs := ctx.coerceVariables(opts.variables); len(errs) > 0 {
+		ctx.Errors = append(ctx.Errors, errs...)
+		return
+	}
+
+	// The operation is validated before any field is resolved, so that
+	// an operation which is invalid for the schema has no response.
+	if errs := ctx.validateOperation(); len(errs) > 0 {
+		return
+	}
+
 	// Construct the root response node
 	ctx.Response = NewResponseNode(nil, nil)
 	ctx.Response.resultType = ctx.Root
-
-	// Begin query execution in the same goroutine
+	ctx.Response.ctx = c
+	ctx.Response.schema = sch
+	ctx.Response.loaders = ctx.loaders
+
+	// The top level fields of a mutation must be executed serially, in
+	// the order in which they appear in the document.
+	ctx.serialExecution = ctx.Operation.OpType == ast.MUTATION
+
+	// Begin query execution in the same goroutine. The root has no
+	// field of its own, but a resolver added for the root type is
+	// called so that it may set the values of the root fields.
+	ctx.loaders.Add(1)
+	expanding = true
+	ctx.Response.selected = selectedFields(ctx.Operation.SelectionSet, ctx.Root, ctx)
+	if res, ok := sch.resolvers[ctx.Root.Name]; ok {
+		res.ResolveGraphQL(ctx.Response)
+	}
+	completeLeaves(ctx.Response, ctx)
 	expandFields(ctx.Operation.SelectionSet, ctx.Response, ctx)
+	expanding = false
+	ctx.loaders.Done()
 	ctx.Response.wg.Wait()
 
 	return
 }
 
-func execute(field *ast.Field, node *ResponseNode, ctx *context) {
+func execute(field *ast.Field, node *ResponseNode, ctx *executionContext) {
 	defer func() {
 		node.parent.wg.Done()
 	}()
 
+	// A panic while resolving a field sets the field to null instead of
+	// crashing the goroutine it is resolved in. Errors raised through
+	// addError have already been recorded.
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				node.null = true
+				if _, ok := r.(errorList); !ok {
+					ctx.appendError(fmt.Errorf("%v", r))
+				}
+			}
+		}()
+
+		resolveField(field, node, ctx)
+	}()
+
+	node.wg.Wait()
+}
+
+// resolveField calls the resolver of a field and schedules its
+// sub-fields, after which the field is no longer an active task of the
+// request's loaders.
+func resolveField(field *ast.Field, node *ResponseNode, ctx *executionContext) {
+	defer ctx.loaders.Done()
+
+	// The field may have been waiting for an Executor to run it since
+	// before the request was cancelled.
+	if ctx.cancelled() {
+		node.null = true
+		return
+	}
+
 	// Process arguments
-	processArguments(&field.Arguments, node, ctx)
+	node.Args = coerceArguments(node.field.Arguments, field.Arguments, ctx)
+	node.selected = selectedFields(field.SelectionSet, node.resultType, ctx)
 
-	// Call the child handler, then wait for all sub-fields to fully
-	// resolve.
+	// Call the child handler, then schedule all sub-fields.
 	resolver := ctx.Schema.resolver(node.resultType.TypeName())
 	resolver.ResolveGraphQL(node)
-	expandFields(field.SelectionSet, node, ctx)
-	node.wg.Wait()
+	completeLeaves(node, ctx)
+	expandItems(field.SelectionSet, node, ctx)
+}
+
+// completeLeaves converts the values of the leaf fields selected on the
+// object resolved by node, or on each of its items, to their types. It
+// must be called before the sub-fields of the node are scheduled, since
+// their resolvers may read the node's values. Covers the completion of
+// leaf values in section 6.4.3 of Execution.
+func completeLeaves(node *ResponseNode, ctx *executionContext) {
+	if node.null {
+		return
+	}
+
+	if node.listType != nil {
+		for _, item := range node.items {
+			completeLeaves(item, ctx)
+		}
+		return
+	}
+
+	for name := range node.selected {
+		field, ok := node.resultType.Field(name)
+		if !ok || ast.IsAbstractType(field.Definition) || node.completed[name] {
+			continue
+		}
+
+		value, ok := node.Get(name)
+		if !ok {
+			ctx.appendError(fmt.Errorf("No value set for field '%s'", name))
+			node.complete(name, nil)
+			if !field.Type.Nullable() {
+				node.null = true
+			}
+			continue
+		}
+
+		v, err := ctx.Schema.coerceResult(reflect.ValueOf(value), field.Type, field.Definition)
+		if err != nil {
+			ctx.appendError(fmt.Errorf("Field '%s': %s", name, err))
+			if !field.Type.Nullable() {
+				node.null = true
+			}
+		}
+		node.complete(name, v)
+	}
 }
 
 // expandFields resolves fragments to compile the list of fields that must
-// be resolved within a given selection set.
-func expandFields(ss ast.SelectionSet, parent *ResponseNode, ctx *context) {
-	def := parent.resultType
+// be resolved within a given selection set. The selection set must
+// already have been validated by validateOperation.
+func expandFields(ss ast.SelectionSet, parent *ResponseNode, ctx *executionContext) {
+	groups := newFieldGroups()
+	groups.collect(ss, parent.resultType, ctx)
+
+	for _, key := range groups.keys {
+		sel := mergeFields(groups.fields[key], ctx)
+
+		name := sel.Name
+		field, ok := ctx.field(parent.resultType, parent == ctx.Response, name)
+		if !ok {
+			ctx.addErrorf("Type has no field named '%s'", name)
+			continue
+		}
+
+		// Register field on parent response node
+		parent.Fields = append(parent.Fields, name)
+
+		// Leaves are read from the values set by the parent's resolver
+		if !ast.IsAbstractType(field.Definition) {
+			parent.selections = append(parent.selections, selection{key: key, field: field})
+			continue
+		}
+
+		// If field is not a leaf, create a ResponseNode for the
+		// given field and execute the field's handler. Only the
+		// root selection set is ever executed serially.
+		node := NewResponseNode(parent, field)
+		node.key = key
+		parent.selections = append(parent.selections, selection{key: key, field: field, node: node})
+		if ctx.cancelled() {
+			node.null = true
+			continue
+		}
+
+		parent.wg.Add(1)
+		ctx.loaders.Add(1)
+
+		run := func() { execute(sel, node, ctx) }
+		if ctx.serialExecution && parent == ctx.Response {
+			runInline(run, ctx)
+		} else {
+			schedule(ctx.executor, run, ctx)
+		}
+	}
+}
+
+// schedule hands a field over to an Executor. This goroutine keeps
+// counting as an active task of the request's loaders while it
+// schedules the remaining fields, so that their keys are dispatched in
+// the same batch, unless the field is resolved inline.
+func schedule(exec Executor, run func(), ctx *executionContext) {
+	if s, ok := exec.(inlineScheduler); ok {
+		s.schedule(run, func() { runInline(run, ctx) })
+		return
+	}
+
+	// Executors of other packages may run the field in this goroutine,
+	// which must then not count as active while the field waits on a
+	// loader.
+	ctx.loaders.Done()
+	exec.Go(run)
+	ctx.loaders.Add(1)
+}
+
+// runInline resolves a field in the goroutine which scheduled it, which
+// stops counting as an active task until the field has been resolved.
+func runInline(run func(), ctx *executionContext) {
+	ctx.loaders.Done()
+	run()
+	ctx.loaders.Add(1)
+}
+
+// fieldGroups holds the fields of a selection set grouped by response
+// key, in the order in which each key first appears. Covers the
+// collection of fields in section 6.3 of Execution.
+type fieldGroups struct {
+	keys   []string
+	fields map[string][]*ast.Field
+
+	// The fragments being spread, so that a fragment which spreads
+	// itself is reported instead of being expanded forever.
+	spreading map[string]bool
+}
+
+func newFieldGroups() *fieldGroups {
+	return &fieldGroups{
+		keys:      make([]string, 0),
+		fields:    make(map[string][]*ast.Field),
+		spreading: make(map[string]bool),
+	}
+}
+
+// collect adds the fields of a selection set on a value of type scope
+// to the groups, expanding fragments and dropping the selections
+// excluded by @skip or @include.
+func (groups *fieldGroups) collect(ss ast.SelectionSet, scope ast.AbstractTypeDefinition, ctx *executionContext) {
 	for _, s := range ss {
 		switch sel := s.(type) {
 		case *ast.FragmentSpread:
@@ -67,54 +337,41 @@ func expandFields(ss ast.SelectionSet, parent *ResponseNode, ctx *context) {
 			frag, ok := ctx.Fragments[sel.Name]
 			if !ok {
 				ctx.addErrorf("No fragment named '%s' found", sel.Name)
+				continue
+			}
+			if groups.spreading[sel.Name] {
+				ctx.addErrorf("Fragment '%s' spreads itself", sel.Name)
+				continue
 			}
 
-			expandFields(frag.SelectionSet, parent, ctx)
+			groups.spreading[sel.Name] = true
+			groups.collect(frag.SelectionSet, ctx.fragmentScope(frag.Type, scope), ctx)
+			delete(groups.spreading, sel.Name)
 
 		case *ast.FragmentDefinition:
 			if !shouldIncludeNode(&sel.Directives, ctx) {
 				continue
 			}
 
-			expandFields(sel.SelectionSet, parent, ctx)
+			groups.collect(sel.SelectionSet, ctx.fragmentScope(sel.Type, scope), ctx)
 
-		// Calls to expandFields eventually reach here once all
-		// fragments have been resolved.
+		// Calls to collect eventually reach here once all fragments
+		// have been resolved.
 		case *ast.Field:
 			if !shouldIncludeNode(&sel.Directives, ctx) {
 				continue
 			}
 
-			name := sel.Name
-			field, ok := def.Field(name)
-			if !ok {
-				ctx.addErrorf("Type has no field named '%s'", name)
-				continue
-			}
-
-			// Register field on parent response node
-			parent.Fields = append(parent.Fields, name)
-
-			// Determine if field is a (valid) leaf
-			if !ast.IsAbstractType(field.Definition) {
-				if len(sel.SelectionSet) != 0 {
-					ctx.addErrorf("Scalar type has sub-fields in query")
-				}
-				continue
-			} else if len(sel.SelectionSet) == 0 {
-				ctx.addErrorf("Abstract type has no sub-fields in query")
-				continue
+			// Fields appear in the response under their alias
+			key := sel.Alias
+			if key == "" {
+				key = sel.Name
 			}
 
-			// If field is not a leaf, create a ResponseNode for the
-			// given field and execute the field's handler.
-			node := NewResponseNode(parent, field)
-			parent.wg.Add(1)
-			if ctx.serialExecution {
-				execute(sel, node, ctx)
-			} else {
-				go execute(sel, node, ctx)
+			if _, ok := groups.fields[key]; !ok {
+				groups.keys = append(groups.keys, key)
 			}
+			groups.fields[key] = append(groups.fields[key], sel)
 
 		default:
 			panic("Unexpected selection type")
@@ -122,21 +379,96 @@ func expandFields(ss ast.SelectionSet, parent *ResponseNode, ctx *context) {
 	}
 }
 
+// selectedFields returns the names of the fields selected by a
+// selection set, so that resolvers may know which fields they must set
+// before the selection set is expanded.
+func selectedFields(ss ast.SelectionSet, def ast.AbstractTypeDefinition, ctx *executionContext) map[string]bool {
+	groups := newFieldGroups()
+	groups.collect(ss, def, ctx)
+
+	names := make(map[string]bool, len(groups.keys))
+	for _, fields := range groups.fields {
+		for _, field := range fields {
+			names[field.Name] = true
+		}
+	}
+
+	return names
+}
+
+// mergeFields merges fields which share a response key into a single
+// field, whose selection set contains the selections of all of them.
+// The fields must select the same field with identical arguments.
+// Covers section 5.2.2 of Validation.
+func mergeFields(fields []*ast.Field, ctx *executionContext) *ast.Field {
+	first := fields[0]
+	if len(fields) == 1 {
+		return first
+	}
+
+	merged := &ast.Field{
+		Name:      first.Name,
+		Alias:     first.Alias,
+		Arguments: first.Arguments,
+	}
+
+	for _, field := range fields {
+		if field.Name != first.Name {
+			ctx.addErrorf("Fields '%s' and '%s' conflict because they have the same response key",
+				first.Name, field.Name)
+			continue
+		}
+
+		if !sameArguments(first.Arguments, field.Arguments) {
+			ctx.addErrorf("Fields named '%s' conflict because they have different arguments", first.Name)
+			continue
+		}
+
+		merged.SelectionSet = append(merged.SelectionSet, field.SelectionSet...)
+	}
+
+	return merged
+}
+
+// sameArguments reports whether two sets of arguments are identical,
+// regardless of their order.
+func sameArguments(args1, args2 ast.Arguments) bool {
+	if len(args1) != len(args2) {
+		return false
+	}
+
+	for _, arg1 := range args1 {
+		found := false
+		for _, arg2 := range args2 {
+			if arg1.Key == arg2.Key {
+				found = reflect.DeepEqual(arg1.Value, arg2.Value)
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Determines whether a node should be included based on the @include
 // and @skip directives, where @skip has higher precedence than @include.
-func shouldIncludeNode(dirs *ast.Directives, ctx *context) bool {
+// The arguments of the directives are coerced like those of a field.
+func shouldIncludeNode(dirs *ast.Directives, ctx *executionContext) bool {
 	shouldInclude := true
 	for _, directive := range *dirs {
 		name := directive.Name
 		if name == "skip" || name == "include" {
-			val, ok := processArgument(&directive.Arguments, "if", ctx)
-			if !ok {
-				continue
-			}
+			decl, _ := ctx.Schema.directives.Field(name)
+			args := coerceArguments(decl.Arguments, directive.Arguments, ctx)
 
-			arg, ok := val.Value().(ast.BooleanValue)
+			// The value is not known when a registered operation is
+			// validated, and the node is then checked as if included.
+			arg, ok := args["if"].(bool)
 			if !ok {
-				ctx.addErrorf("Value given to @skip or @include must be Boolean")
 				continue
 			}
 
@@ -150,44 +482,3 @@ func shouldIncludeNode(dirs *ast.Directives, ctx *context) bool {
 
 	return shouldInclude
 }
-
-// processArgument extracts the argument with the given name from an
-// arguments ast node, performing variable substitution.
-func processArgument(args *ast.Arguments, name string, ctx *context) (ast.Value, bool) {
-	for _, arg := range *args {
-		if arg.Key != name {
-			continue
-		}
-
-		// Do variable lookup
-		if varName, ok := arg.Value.(ast.VariableValue); ok {
-			if varValue, ok := ctx.Variables[string(varName)]; ok {
-				return varValue, true
-			} else {
-				ctx.addErrorf("Undefined variable $'%s'", varName)
-				return nil, false
-			}
-		}
-
-		return arg.Value, true
-	}
-
-	return nil, false
-}
-
-func processArguments(args *ast.Arguments, out *ResponseNode, ctx *context) {
-	for _, arg := range *args {
-		// Do variable lookup
-		if varName, ok := arg.Value.(ast.VariableValue); ok {
-			if varValue, ok := ctx.Variables[string(varName)]; ok {
-				out.Args[arg.Key] = varValue.Value()
-			} else {
-				ctx.addErrorf("Undefined variable $'%s'", varName)
-			}
-		}
-
-		out.Args[arg.Key] = arg.Value.Value()
-	}
-
-	return
-}
@@ -1,22 +1,393 @@
 package schema
 
 import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"dylanmackenzie.com/graphql/ast"
 )
 
-func TestProcessArgument(t *testing.T) {
-	ctx := NewContext(nil)
-	ctx.Variables["ifVar"] = ast.BooleanValue(true)
+var bankSchema = `
+type Owner {
+  name: String
+}
+
+type Bank {
+  name: String
+}
+
+type Account {
+  balance: Int
+  owner: Owner
+  bank: Bank
+}
+
+type Query {
+  account: Account
+}
+
+type Mutation {
+  deposit(amount: Int): Account
+  withdraw(amount: Int): Account
+}
+`
+
+// newTestSchema parses a schema document and registers the given root
+// types. It does not finalize the schema so that resolvers may still be
+// added.
+func newTestSchema(t testing.TB, doc, query, mutation string) *Schema {
+	d, err := ast.FromReader(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sch := New()
+	sch.AddDocument(&d)
+	sch.Root("query", query)
+	if mutation != "" {
+		sch.Root("mutation", mutation)
+	}
+
+	return sch
+}
+
+// executeQuery executes the anonymous operation of a query against a
+// finalized schema.
+func executeQuery(t *testing.T, sch *Schema, query string) (*ResponseNode, error) {
+	doc, err := ast.FromReader(strings.NewReader(query))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := Execute(context.Background(), sch, &doc, "")
+	return ctx.Response, err
+}
+
+func TestMutationSerialExecution(t *testing.T) {
+	sch := newTestSchema(t, bankSchema, "Query", "Mutation")
+
+	var mu sync.Mutex
+	balance := 0
+	order := make([]string, 0)
+	running := ""
+
+	sch.AddResolveFunc("Account", func(r *ResponseNode) {
+		amount, _ := r.Args.GetAsInt("amount")
+		if r.name == "withdraw" {
+			amount = -amount
+		}
+
+		mu.Lock()
+		if running != "" {
+			t.Errorf("Mutation '%s' started while '%s' was running", r.name, running)
+		}
+		running = r.name
+		mu.Unlock()
+
+		// Let the other mutation start if it has been scheduled, which
+		// it must not be until this one has returned.
+		runtime.Gosched()
+
+		mu.Lock()
+		running = ""
+		balance += amount
+		order = append(order, r.name)
+		r.Set("balance", balance)
+		mu.Unlock()
+	})
+	sch.Finalize()
+
+	doc, err := ast.FromReader(strings.NewReader(`
+		mutation Transfer {
+			deposit(amount: 10) { balance }
+			withdraw(amount: 4) { balance }
+		}
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := Execute(context.Background(), sch, &doc, "Transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(order) != 2 || order[0] != "deposit" || order[1] != "withdraw" {
+		t.Errorf("Expected mutations to run in document order, got %v", order)
+	}
+
+	res, err := ctx.Response.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{"deposit":{"balance":10},"withdraw":{"balance":6}}`
+	if string(res) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, res)
+	}
+}
+
+func TestMutationNestedParallelExecution(t *testing.T) {
+	sch := newTestSchema(t, bankSchema, "Query", "Mutation")
+
+	// Both nested resolvers wait for each other, so they can only
+	// complete if they are executed in parallel.
+	barrier := new(sync.WaitGroup)
+	barrier.Add(2)
+	wait := func(r *ResponseNode) {
+		barrier.Done()
+		done := make(chan struct{})
+		go func() {
+			barrier.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Errorf("Nested field '%s' was not executed in parallel", r.name)
+		}
+		r.Set("name", r.name)
+	}
+
+	sch.AddResolveFunc("Account", func(r *ResponseNode) {})
+	sch.AddResolveFunc("Owner", wait)
+	sch.AddResolveFunc("Bank", wait)
+	sch.Finalize()
+
+	doc, err := ast.FromReader(strings.NewReader(`
+		mutation Deposit {
+			deposit(amount: 10) {
+				owner { name }
+				bank { name }
+			}
+		}
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	args := &ast.Arguments{
-		{"if", ast.VariableValue("ifVar")},
+	if _, err := Execute(context.Background(), sch, &doc, "Deposit"); err != nil {
+		t.Fatal(err)
 	}
+}
+
+type testKey struct{}
+
+func TestExecuteCancelled(t *testing.T) {
+	sch := newTestSchema(t, bankSchema, "Query", "Mutation")
+
+	c, cancel := context.WithCancel(context.WithValue(context.Background(), testKey{}, 10))
+	defer cancel()
+
+	sch.AddResolveFunc("Account", func(r *ResponseNode) {
+		balance, _ := r.Context().Value(testKey{}).(int)
+		r.Set("balance", balance)
+
+		// The sub-fields of the account should not be resolved
+		cancel()
+	})
+	sch.AddResolveFunc("Owner", func(r *ResponseNode) {
+		t.Error("Owner resolved after the request was cancelled")
+	})
+	sch.Finalize()
+
+	doc, err := ast.FromReader(strings.NewReader(`
+		{
+			account {
+				balance
+				owner { name }
+			}
+		}
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := Execute(c, sch, &doc, "")
+	if errs, ok := err.(errorList); !ok || len(errs) != 1 || errs[0] != context.Canceled {
+		t.Errorf("Expected '%v', got '%v'", context.Canceled, err)
+	}
+
+	res, err := ctx.Response.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{"account":{"balance":10,"owner":null}}`
+	if string(res) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, res)
+	}
+}
+
+func TestExecuteNilContext(t *testing.T) {
+	sch := newTestSchema(t, bankSchema, "Query", "Mutation")
+	sch.AddResolveFunc("Account", func(r *ResponseNode) {
+		if r.Context() == nil {
+			t.Error("Expected a context")
+		}
+		r.Set("balance", 10)
+	})
+	sch.AddResolveFunc("Owner", func(r *ResponseNode) {
+		r.Set("name", "Luke")
+	})
+	sch.Finalize()
+
+	doc, err := ast.FromReader(strings.NewReader(`{ account { balance owner { name } } }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := ExecuteWith(nil, nil, sch, &doc, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := ctx.Response.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{"account":{"balance":10,"owner":{"name":"Luke"}}}`
+	if string(res) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, res)
+	}
+}
+
+var heroSchema = `
+type Character {
+  id: Int
+  name(short: Boolean): String
+}
+
+type Query {
+  hero(id: Int): Character
+}
+`
+
+func TestAliases(t *testing.T) {
+	sch := newTestSchema(t, heroSchema, "Query", "")
+	sch.AddResolveFunc("Character", func(r *ResponseNode) {
+		id, _ := r.Args.GetAsInt("id")
+		r.Set("id", id)
+		r.Set("name", humans[id].name)
+	})
+	sch.Finalize()
+
+	doc, err := ast.FromReader(strings.NewReader(`
+		{
+			b: hero(id: 2) { name theId: id }
+			a: hero(id: 1) { id name }
+			hero(id: 3) { name }
+		}
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := Execute(context.Background(), sch, &doc, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := ctx.Response.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{"b":{"name":"Han","theId":2},"a":{"id":1,"name":"Luke"},"hero":{"name":"Leia"}}`
+	if string(res) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, res)
+	}
+
+	for _, child := range ctx.Response.children {
+		if child.Name() != "hero" {
+			t.Errorf("Expected aliased field to be named 'hero', got '%s'", child.Name())
+		}
+	}
+}
+
+func TestFieldMerging(t *testing.T) {
+	sch := newTestSchema(t, heroSchema, "Query", "")
+
+	var mu sync.Mutex
+	calls := 0
+	sch.AddResolveFunc("Character", func(r *ResponseNode) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+
+		id, _ := r.Args.GetAsInt("id")
+		r.Set("id", id)
+		r.Set("name", humans[id].name)
+	})
+	sch.Finalize()
+
+	doc, err := ast.FromReader(strings.NewReader(`
+		query Merged {
+			hero(id: 1) { name }
+			...HeroID
+			luke: hero(id: 1) { id }
+			hero(id: 1) { name id }
+		}
+
+		fragment HeroID on Query {
+			hero(id: 1) { id }
+			luke: hero(id: 1) { name }
+		}
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := Execute(context.Background(), sch, &doc, "Merged")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 2 {
+		t.Errorf("Expected each response key to be resolved once, got %d calls", calls)
+	}
+
+	res, err := ctx.Response.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{"hero":{"name":"Luke","id":1},"luke":{"name":"Luke","id":1}}`
+	if string(res) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, res)
+	}
+}
+
+func TestFieldMergingConflicts(t *testing.T) {
+	sch := newTestSchema(t, heroSchema, "Query", "")
+	sch.AddResolveFunc("Character", func(r *ResponseNode) {
+		r.Set("id", 1)
+		r.Set("name", "Luke")
+	})
+	sch.Finalize()
+
+	conflicts := map[string]string{
+		"Arguments":       `query Q { hero(id: 1) { name } hero(id: 2) { name } }`,
+		"MissingArgument": `query Q { hero(id: 1) { name } hero { name } }`,
+		"Names":           `query Q { hero(id: 1) { name: id name } }`,
+		"Fragment":        `query Q { hero(id: 1) { name ...F } } fragment F on Character { name: id }`,
+		"LeafArguments":   `query Q { hero(id: 1) { a: name(short: true) b: name(short: false) } }`,
+	}
+
+	for name, query := range conflicts {
+		doc, err := ast.FromReader(strings.NewReader(query))
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
 
-	expect := ast.BooleanValue(true)
-	val, ok := processArgument(args, "if", ctx)
-	if !ok || val != expect {
-		t.Errorf("Expected '%s', got '%s'\n", expect, val)
+		if _, err := Execute(context.Background(), sch, &doc, "Q"); err == nil {
+			t.Errorf("%s: Expected conflicting fields to be rejected", name)
+		}
 	}
 }
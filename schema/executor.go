@@ -0,0 +1,86 @@
+package schema
+
+// An Executor schedules the fields of a request for resolution. Every
+// non-leaf field in a request is passed to its Executor as a function
+// which resolves the field and all of its sub-fields.
+type Executor interface {
+	// Go runs f, either in the calling goroutine or in a new one. It
+	// must eventually call f exactly once.
+	Go(f func())
+}
+
+// An inlineScheduler is an Executor which distinguishes the functions
+// it runs in the calling goroutine from those it runs in a new one.
+type inlineScheduler interface {
+	// schedule either runs f in a new goroutine, or calls inline in the
+	// calling goroutine.
+	schedule(f, inline func())
+}
+
+type serialExecutor struct{}
+
+func (serialExecutor) Go(f func()) {
+	f()
+}
+
+func (serialExecutor) schedule(f, inline func()) {
+	inline()
+}
+
+// SerialExecutor returns an Executor which resolves every field of a
+// request in the calling goroutine, one after the other.
+func SerialExecutor() Executor {
+	return serialExecutor{}
+}
+
+type parallelExecutor struct{}
+
+func (parallelExecutor) Go(f func()) {
+	go f()
+}
+
+func (parallelExecutor) schedule(f, inline func()) {
+	go f()
+}
+
+// ParallelExecutor returns an Executor which resolves every field of a
+// request in its own goroutine. This is the default.
+func ParallelExecutor() Executor {
+	return parallelExecutor{}
+}
+
+type boundedExecutor chan struct{}
+
+func (sem boundedExecutor) Go(f func()) {
+	sem.schedule(f, f)
+}
+
+func (sem boundedExecutor) schedule(f, inline func()) {
+	select {
+	case sem <- struct{}{}:
+		go func() {
+			defer func() { <-sem }()
+			f()
+		}()
+	default:
+		// Every worker is busy, so we resolve the field ourselves.
+		// Since workers wait on the fields they spawn, blocking here
+		// until a worker is free could deadlock.
+		inline()
+	}
+}
+
+// BoundedExecutor returns an Executor which resolves fields using at
+// most n goroutines in addition to the one which began the request.
+// When all n goroutines are busy, fields are resolved in the goroutine
+// which scheduled them.
+//
+// Each call returns a new pool, so a schema should create one per
+// request to bound the resources used by each request individually.
+func BoundedExecutor(n int) Executor {
+	if n <= 0 {
+		return SerialExecutor()
+	}
+
+	return make(boundedExecutor, n)
+}
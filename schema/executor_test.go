@@ -0,0 +1,245 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"dylanmackenzie.com/graphql/ast"
+	"dylanmackenzie.com/graphql/dataloader"
+)
+
+var treeSchema = `
+type Tree {
+  value: Int
+  left: Tree
+  right: Tree
+}
+
+type Query {
+  tree: Tree
+}
+`
+
+// treeQuery builds a query which selects a complete binary tree of the
+// given depth, resulting in 2^depth - 1 non-leaf fields.
+func treeQuery(depth int) string {
+	var selection func(depth int) string
+	selection = func(depth int) string {
+		if depth == 1 {
+			return "{ value }"
+		}
+
+		sub := selection(depth - 1)
+		return fmt.Sprintf("{ value left %s right %s }", sub, sub)
+	}
+
+	return "{ tree " + selection(depth) + " }"
+}
+
+var executors = map[string]func() Executor{
+	"Serial":    SerialExecutor,
+	"Parallel":  ParallelExecutor,
+	"Bounded1":  func() Executor { return BoundedExecutor(1) },
+	"Bounded4":  func() Executor { return BoundedExecutor(4) },
+	"Bounded64": func() Executor { return BoundedExecutor(64) },
+}
+
+func TestExecutors(t *testing.T) {
+	var expect []byte
+	for _, name := range []string{"Serial", "Parallel", "Bounded1", "Bounded4", "Bounded64"} {
+		sch := newTestSchema(t, treeSchema, "Query", "")
+		sch.AddResolveFunc("Tree", func(r *ResponseNode) {
+			r.Set("value", len(r.name))
+		})
+		sch.NewExecutor = executors[name]
+		sch.Finalize()
+
+		node, err := executeQuery(t, sch, treeQuery(6))
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		res, err := node.MarshalJSON()
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		if expect == nil {
+			expect = res
+		} else if !bytes.Equal(expect, res) {
+			t.Errorf("%s: Expected '%s', got '%s'", name, expect, res)
+		}
+	}
+}
+
+func TestBoundedExecutor(t *testing.T) {
+	const limit = 3
+
+	var mu sync.Mutex
+	running := make(map[int64]bool)
+	var next, max int64
+
+	sch := newTestSchema(t, treeSchema, "Query", "")
+	sch.AddResolveFunc("Tree", func(r *ResponseNode) {
+		id := atomic.AddInt64(&next, 1)
+
+		mu.Lock()
+		running[id] = true
+		if n := int64(len(running)); n > max {
+			max = n
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		delete(running, id)
+		mu.Unlock()
+
+		r.Set("value", 0)
+	})
+	sch.Finalize()
+
+	doc, err := ast.FromReader(strings.NewReader(treeQuery(6)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The executor passed to ExecuteWith takes precedence over the
+	// schema's executor.
+	sch.NewExecutor = ParallelExecutor
+	if _, err := ExecuteWith(context.Background(), BoundedExecutor(limit), sch, &doc, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// The goroutine which began the request may also resolve fields.
+	if max > limit+1 {
+		t.Errorf("Expected at most %d concurrent resolvers, got %d", limit+1, max)
+	}
+}
+
+func benchmarkExecutor(b *testing.B, newExecutor func() Executor) {
+	sch := newTestSchema(b, treeSchema, "Query", "")
+	sch.AddResolveFunc("Tree", func(r *ResponseNode) {
+		sum := 0
+		for i := 0; i < 1000; i++ {
+			sum += i
+		}
+		r.Set("value", sum)
+	})
+	sch.NewExecutor = newExecutor
+	sch.Finalize()
+
+	doc, err := ast.FromReader(strings.NewReader(treeQuery(6)))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Execute(context.Background(), sch, &doc, ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSerialExecutor(b *testing.B)    { benchmarkExecutor(b, executors["Serial"]) }
+func BenchmarkParallelExecutor(b *testing.B)  { benchmarkExecutor(b, executors["Parallel"]) }
+func BenchmarkBoundedExecutor1(b *testing.B)  { benchmarkExecutor(b, executors["Bounded1"]) }
+func BenchmarkBoundedExecutor4(b *testing.B)  { benchmarkExecutor(b, executors["Bounded4"]) }
+func BenchmarkBoundedExecutor64(b *testing.B) { benchmarkExecutor(b, executors["Bounded64"]) }
+
+func TestExecutorLoaderBatches(t *testing.T) {
+	for _, name := range []string{"Parallel", "Bounded64"} {
+		var mu sync.Mutex
+		sizes := make([]int, 0)
+
+		sch := newTestSchema(t, treeSchema, "Query", "")
+		sch.AddResolveFunc("Tree", func(r *ResponseNode) {
+			// Every node loads a distinct key
+			key := fmt.Sprintf("%p/%s", r.parent, r.name)
+			v, err := r.Loader("Tree").Load(key)
+			if err != nil {
+				t.Error(err)
+			}
+			r.Set("value", v)
+		})
+		sch.NewExecutor = executors[name]
+		sch.AddLoader("Tree", func(c context.Context, keys []interface{}) []dataloader.Result {
+			mu.Lock()
+			sizes = append(sizes, len(keys))
+			mu.Unlock()
+
+			results := make([]dataloader.Result, len(keys))
+			for i := range keys {
+				results[i].Value = len(keys)
+			}
+			return results
+		})
+		sch.Finalize()
+
+		if _, err := executeQuery(t, sch, treeQuery(6)); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		// One batch per level of the tree
+		expect := []int{1, 2, 4, 8, 16, 32}
+		if fmt.Sprint(sizes) != fmt.Sprint(expect) {
+			t.Errorf("%s: Expected batches of sizes %v, got %v", name, expect, sizes)
+		}
+	}
+}
+
+func TestExecutorLoaderListBatch(t *testing.T) {
+	sch := newTestSchema(t, `
+		type Pet {
+		  name: String
+		}
+
+		type Human {
+		  pet: Pet
+		}
+
+		type Query {
+		  humans: [Human]
+		}
+	`, "Query", "")
+
+	var mu sync.Mutex
+	sizes := make([]int, 0)
+	sch.AddLoader("Pet", func(c context.Context, keys []interface{}) []dataloader.Result {
+		mu.Lock()
+		sizes = append(sizes, len(keys))
+		mu.Unlock()
+
+		return make([]dataloader.Result, len(keys))
+	})
+	sch.AddResolveFunc("Human", func(r *ResponseNode) {
+		r.SetLength(200)
+		for i := 0; i < 200; i++ {
+			r.Item(i).Set("id", i)
+		}
+	})
+	sch.AddResolveFunc("Pet", func(r *ResponseNode) {
+		id, _ := r.Parent().GetAsInt("id")
+		if _, err := r.Loader("Pet").Load(id); err != nil {
+			t.Error(err)
+		}
+		r.Set("name", "")
+	})
+	sch.Finalize()
+
+	if _, err := executeQuery(t, sch, `{ humans { pet { name } } }`); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(sizes) != "[200]" {
+		t.Errorf("Expected the pets to be loaded in one batch, got batches of sizes %v", sizes)
+	}
+}
@@ -0,0 +1,72 @@
+package schema
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// The GraphiQL page is a single HTML document with its script and style
+// inlined, so that it can be served from the GraphQL endpoint itself
+// and needs nothing from other hosts.
+//
+//go:embed graphiql
+var graphiqlFiles embed.FS
+
+var graphiqlPage = template.Must(template.ParseFS(graphiqlFiles, "graphiql/index.html"))
+
+// GraphiQLHandler returns an http.Handler which serves a GraphiQL page
+// for exploring the GraphQL endpoint at the given URL, such as the one
+// served by Schema.Handler. The documentation it shows is read by
+// introspection, so it is empty if introspection is disabled. If
+// endpoint is empty, the page queries the URL it was served from.
+func GraphiQLHandler(endpoint string) http.Handler {
+	page, err := renderGraphiQL(endpoint)
+	if err != nil {
+		log.Panicf("Could not render GraphiQL page: %s", err)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	})
+}
+
+func renderGraphiQL(endpoint string) ([]byte, error) {
+	script, err := graphiqlFiles.ReadFile("graphiql/graphiql.js")
+	if err != nil {
+		return nil, err
+	}
+	style, err := graphiqlFiles.ReadFile("graphiql/graphiql.css")
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = graphiqlPage.Execute(&buf, struct {
+		Endpoint string
+		Script   template.JS
+		Style    template.CSS
+	}{endpoint, template.JS(script), template.CSS(style)})
+	return buf.Bytes(), err
+}
+
+// wantsHTML reports whether a request was made by a browser navigating
+// to the endpoint, rather than by a GraphQL client.
+func wantsHTML(r *http.Request) bool {
+	if r.Method != "GET" {
+		return false
+	}
+
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		if t, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && t == "text/html" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,254 @@
+(function () {
+  'use strict';
+
+  var $ = function (id) { return document.getElementById(id); };
+  var query = $('query'), variables = $('variables'), headers = $('headers');
+  var result = $('result'), status = $('status'), operation = $('operation');
+  var docs = $('docs');
+
+  // The editors are restored from the URL, so that a link can share a
+  // query, or otherwise from the previous session.
+  var params = new URLSearchParams(location.search);
+  [query, variables, headers].forEach(function (editor) {
+    var saved = params.get(editor.id) || localStorage.getItem('graphiql:' + editor.id);
+    if (saved) {
+      editor.value = saved;
+    }
+    editor.addEventListener('input', function () {
+      localStorage.setItem('graphiql:' + editor.id, editor.value);
+    });
+  });
+
+  function parseJSON(editor, name) {
+    var text = editor.value.trim();
+    if (!text) {
+      return {};
+    }
+    try {
+      return JSON.parse(text);
+    } catch (e) {
+      throw new Error(name + ' are not valid JSON: ' + e.message);
+    }
+  }
+
+  function fetchGraphQL(body) {
+    var h = { 'Content-Type': 'application/json', 'Accept': 'application/graphql-response+json, application/json' };
+    var extra = parseJSON(headers, 'Headers');
+    Object.keys(extra).forEach(function (key) { h[key] = extra[key]; });
+
+    return fetch(endpoint || location.pathname, {
+      method: 'POST',
+      headers: h,
+      body: JSON.stringify(body),
+      credentials: 'same-origin'
+    }).then(function (res) {
+      return res.text().then(function (text) {
+        return { status: res.status, text: text };
+      });
+    });
+  }
+
+  // Operations
+
+  function operationNames() {
+    var re = /(?:^|[\s}])(?:query|mutation|subscription)\s+([_A-Za-z][_0-9A-Za-z]*)/g;
+    var names = [], match;
+    while ((match = re.exec(query.value)) !== null) {
+      names.push(match[1]);
+    }
+    return names;
+  }
+
+  function updateOperations() {
+    var names = operationNames();
+    var selected = operation.value;
+    operation.innerHTML = '';
+    names.forEach(function (name) {
+      var option = document.createElement('option');
+      option.value = option.textContent = name;
+      operation.appendChild(option);
+    });
+    if (names.indexOf(selected) >= 0) {
+      operation.value = selected;
+    }
+    operation.hidden = names.length < 2;
+  }
+
+  function run() {
+    var started = Date.now();
+    var body;
+    try {
+      body = { query: query.value, variables: parseJSON(variables, 'Variables') };
+    } catch (e) {
+      result.textContent = e.message;
+      return;
+    }
+    if (!operation.hidden && operation.value) {
+      body.operationName = operation.value;
+    }
+
+    status.textContent = 'Running...';
+    fetchGraphQL(body).then(function (res) {
+      status.textContent = res.status + ' in ' + (Date.now() - started) + 'ms';
+      try {
+        result.textContent = JSON.stringify(JSON.parse(res.text), null, 2);
+      } catch (e) {
+        result.textContent = res.text;
+      }
+    }).catch(function (e) {
+      status.textContent = '';
+      result.textContent = e.message;
+    });
+  }
+
+  // prettify reindents the query by the nesting of its braces and
+  // parentheses, leaving strings and comments intact.
+  function prettify() {
+    var out = '', depth = 0, i, c, line;
+    var lines = query.value.split('\n');
+    for (i = 0; i < lines.length; i++) {
+      line = lines[i].trim();
+      if (!line) {
+        continue;
+      }
+      var opening = 0, closing = 0, inString = false;
+      for (var j = 0; j < line.length; j++) {
+        c = line[j];
+        if (inString) {
+          if (c === '\\') { j++; } else if (c === '"') { inString = false; }
+        } else if (c === '"') {
+          inString = true;
+        } else if (c === '#') {
+          break;
+        } else if (c === '{' || c === '(') {
+          opening++;
+        } else if (c === '}' || c === ')') {
+          if (opening > 0) { opening--; } else { closing++; }
+        }
+      }
+      depth = Math.max(0, depth - closing);
+      out += new Array(depth + 1).join('  ') + line + '\n';
+      depth += opening;
+    }
+    query.value = out;
+    localStorage.setItem('graphiql:query', out);
+  }
+
+  // Documentation
+
+  var introspectionQuery = 'query IntrospectionQuery { __schema { ' +
+    'queryType { name } mutationType { name } ' +
+    'types { name kind description ' +
+    'fields { name description type { ...TypeRef } args { name type { ...TypeRef } } } ' +
+    'inputFields { name description type { ...TypeRef } } ' +
+    'enumValues { name description } } } } ' +
+    'fragment TypeRef on __Type { name kind ofType { name kind ofType { name kind ofType { name kind } } } }';
+
+  var types = null;
+
+  function typeName(t) {
+    if (t.kind === 'NON_NULL') {
+      return typeName(t.ofType) + '!';
+    }
+    if (t.kind === 'LIST') {
+      return '[' + typeName(t.ofType) + ']';
+    }
+    return t.name;
+  }
+
+  function baseName(t) {
+    return t.ofType ? baseName(t.ofType) : t.name;
+  }
+
+  function link(t) {
+    var a = document.createElement('a');
+    a.textContent = typeName(t);
+    a.addEventListener('click', function () { showType(baseName(t)); });
+    return a;
+  }
+
+  function element(tag, text, className) {
+    var e = document.createElement(tag);
+    e.textContent = text || '';
+    if (className) {
+      e.className = className;
+    }
+    return e;
+  }
+
+  function showType(name) {
+    var t = types[name];
+    docs.innerHTML = '';
+    docs.appendChild(element('h2', t.name));
+    if (t.description) {
+      docs.appendChild(element('p', t.description, 'description'));
+    }
+
+    (t.fields || t.inputFields || []).forEach(function (f) {
+      var div = element('div', f.name, 'field');
+      if (f.args && f.args.length) {
+        div.appendChild(document.createTextNode('('));
+        f.args.forEach(function (arg, i) {
+          div.appendChild(document.createTextNode((i ? ', ' : '') + arg.name + ': '));
+          div.appendChild(link(arg.type));
+        });
+        div.appendChild(document.createTextNode(')'));
+      }
+      div.appendChild(document.createTextNode(': '));
+      div.appendChild(link(f.type));
+      docs.appendChild(div);
+      if (f.description) {
+        docs.appendChild(element('div', f.description, 'description'));
+      }
+    });
+
+    (t.enumValues || []).forEach(function (v) {
+      docs.appendChild(element('div', v.name, 'field'));
+    });
+  }
+
+  function showRoots(schema) {
+    docs.innerHTML = '';
+    docs.appendChild(element('h2', 'Schema'));
+    [['query', schema.queryType], ['mutation', schema.mutationType]].forEach(function (root) {
+      if (root[1]) {
+        var div = element('div', root[0] + ': ', 'field');
+        div.appendChild(link({ name: root[1].name }));
+        docs.appendChild(div);
+      }
+    });
+  }
+
+  function toggleDocs() {
+    docs.hidden = !docs.hidden;
+    if (docs.hidden || types) {
+      return;
+    }
+
+    docs.textContent = 'Loading...';
+    fetchGraphQL({ query: introspectionQuery, operationName: 'IntrospectionQuery' }).then(function (res) {
+      var body = JSON.parse(res.text);
+      if (!body.data) {
+        throw new Error((body.errors || []).map(function (e) { return e.message; }).join('\n'));
+      }
+      types = {};
+      body.data.__schema.types.forEach(function (t) { types[t.name] = t; });
+      showRoots(body.data.__schema);
+    }).catch(function (e) {
+      docs.textContent = 'Could not introspect the schema: ' + e.message;
+    });
+  }
+
+  query.addEventListener('input', updateOperations);
+  document.addEventListener('keydown', function (e) {
+    if ((e.ctrlKey || e.metaKey) && e.key === 'Enter') {
+      e.preventDefault();
+      run();
+    }
+  });
+  $('run').addEventListener('click', run);
+  $('prettify').addEventListener('click', prettify);
+  $('toggle-docs').addEventListener('click', toggleDocs);
+
+  updateOperations();
+})();
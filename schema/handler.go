@@ -1,83 +1,676 @@
 package schema
 
 import (
-	"io"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"log"
+	"mime"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"dylanmackenzie.com/graphql/ast"
 )
 
+// The media types of GraphQL requests and responses, as defined by the
+// GraphQL over HTTP specification.
+const (
+	mediaTypeJSON            = "application/json"
+	mediaTypeGraphQL         = "application/graphql"
+	mediaTypeGraphQLResponse = "application/graphql-response+json"
+)
+
+// DefaultMaxBatchSize is the maximum number of operations in a batched
+// request if HandlerOptions.MaxBatchSize is zero.
+const DefaultMaxBatchSize = 10
+
+// RequestInfo holds the parameters of a GraphQL request, which are
+// given in the URL of a GET request or the body of a POST request.
 type RequestInfo struct {
-	Document  io.Reader
-	Variables string
-	Operation string
+	// The GraphQL document to be executed.
+	Query string `json:"query"`
+
+	// The values of the variables declared by the operation.
+	Variables map[string]interface{} `json:"variables"`
+
+	// The name of the operation to execute, which may be omitted if
+	// the document contains only one.
+	Operation string `json:"operationName"`
+
+	// Additional information about the request, for use by
+	// extensions to the protocol.
+	Extensions map[string]interface{} `json:"extensions"`
+
+	// The ID of a document registered in the OperationManifest of the
+	// handler, which is given in place of Query.
+	DocumentID string `json:"documentId"`
 }
 
-// query:  A string GraphQL document to be executed.
+// HandlerOptions configures the http.Handler returned by
+// Schema.HandlerWithOptions. The zero value is the configuration used
+// by Schema.Handler.
+type HandlerOptions struct {
+	// The maximum duration of the execution of a request. Once it has
+	// elapsed, the fields which have not yet been resolved are set to
+	// null and the partial response is returned along with an error.
+	// There is no limit if Timeout is zero.
+	Timeout time.Duration
+
+	// The maximum size in bytes of the body of a POST request. Larger
+	// requests are rejected with status 413. There is no limit if
+	// MaxBodyBytes is zero.
+	MaxBodyBytes int64
+
+	// The maximum length in bytes of the GraphQL document of a
+	// request. There is no limit if MaxQueryLength is zero.
+	MaxQueryLength int
+
+	// The HTTP methods which are accepted, which may be GET and POST.
+	// Both are accepted if Methods is empty.
+	Methods []string
+
+	// The maximum number of operations in a batched request, which is
+	// rejected with status 413 if it holds more. The limit is
+	// DefaultMaxBatchSize if MaxBatchSize is zero, and batching is
+	// disabled if it is negative.
+	MaxBatchSize int
+
+	// The store of automatic persisted queries, which lets clients send
+	// the SHA-256 hash of a document which they have sent before in
+	// place of the document itself. Persisted queries are not supported
+	// if PersistedQueries is nil.
+	PersistedQueries PersistedQueryStore
+
+	// The documents which clients may execute by their ID, rather than
+	// giving the document itself.
+	Manifest *OperationManifest
+
+	// Whether only the documents of the Manifest may be executed, in
+	// which case requests which give a document of their own are
+	// rejected.
+	StrictManifest bool
+
+	// Whether the __schema and __type fields used to introspect the
+	// schema are hidden.
+	DisableIntrospection bool
+
+	// Whether POST requests must be ones which a browser would only
+	// send cross-origin after a CORS preflight request, which protects
+	// against cross-site request forgery. Such requests either have a
+	// Content-Type other than application/x-www-form-urlencoded,
+	// multipart/form-data and text/plain, or set the
+	// GraphQL-Require-Preflight header.
+	CSRFProtection bool
+
+	// Whether a GraphiQL page for exploring the schema is served in
+	// reply to GET requests whose Accept header lists text/html, as
+	// sent by a browser. It is intended for development, and depends on
+	// introspection being enabled.
+	GraphiQL bool
+
+	// FormatError converts each error of a response to the value which
+	// is written in its errors list. If nil, errors are written as
+	// objects holding their message.
+	FormatError func(err error) interface{}
+
+	// Logger is called once each request has been answered.
+	Logger func(entry RequestLog)
+
+	// Context derives the context in which a request is executed from
+	// the HTTP request, for instance to add the authenticated user. It
+	// is available to resolvers through ResponseNode.Context. If nil,
+	// the context of the HTTP request is used.
+	Context func(r *http.Request) context.Context
+}
+
+// A RequestLog describes a request which has been answered by the
+// handler.
+type RequestLog struct {
+	Request  *http.Request
+	Info     *RequestInfo   // nil if the request could not be read or was batched.
+	Batch    []*RequestInfo // The operations of a batched request.
+	Status   int
+	Duration time.Duration
+	Errors   []error // The errors of the response.
+}
+
+// response is the body written in reply to a GraphQL request.
+type response struct {
+	Data   *ResponseNode `json:"data,omitempty"`
+	Errors []interface{} `json:"errors,omitempty"`
+}
+
+// responseError is the default format of the errors of a response.
+type responseError struct {
+	Message    string                 `json:"message"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// An extensionsError is an error which gives additional information to
+// clients in the extensions of its response error.
+type extensionsError interface {
+	error
+	Extensions() map[string]interface{}
+}
+
+func formatError(err error) interface{} {
+	res := responseError{Message: err.Error()}
+	if e, ok := err.(extensionsError); ok {
+		res.Extensions = e.Extensions()
+	}
+	return res
+}
+
+// An httpError is a problem with an HTTP request which prevents it from
+// being read as a GraphQL request at all.
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string { return e.message }
+
+// Handler returns an http.Handler which executes GraphQL requests
+// against the schema, following the GraphQL over HTTP specification.
 //
-// variables: The runtime values to use for any GraphQL query variables
-// as a JSON object.
+// A GET request gives its parameters in the URL:
 //
-// operationName: If the provided query contains multiple named
-// operations, this specifies which operation should be executed.  If
-// not provided, a 400 error will be returned if the query contains
-// multiple named operations.
+//	query          The GraphQL document to be executed.
+//	variables      The values of the variables, as a JSON object.
+//	operationName  The operation to execute, if the document contains
+//	               more than one.
+//	extensions     Additional information, as a JSON object.
+//	documentId     The ID of a document registered in the Manifest of
+//	               HandlerOptions, in place of query.
+//
+// GET requests may only execute queries, and are answered with status
+// 405 if the operation is a mutation.
+//
+// A POST request with the content type application/json gives the same
+// parameters as the fields of a JSON object in its body. A POST request
+// with the content type application/graphql gives the document as its
+// body, and any other parameters in the URL.
+//
+// A POST request with the content type application/json may instead
+// give a list of such objects to execute a batch of operations
+// concurrently. The response is then a list of the results of each
+// operation, in the same order, and has status 200 even if some
+// operations fail.
+//
+// The response is written as application/graphql-response+json if the
+// Accept header of the request allows it, and otherwise as
+// application/json. For the former, a request which fails before it is
+// executed, for instance because its document is invalid, is answered
+// with status 400, while application/json responses to any well-formed
+// request use status 200 for compatibility with older clients.
 func (sch *Schema) Handler() http.Handler {
+	return sch.HandlerWithOptions(HandlerOptions{})
+}
+
+// HandlerWithOptions is like Handler, but configured by opts.
+func (sch *Schema) HandlerWithOptions(opts HandlerOptions) http.Handler {
 	sch.Finalize()
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// GET requests can't have bodies, so parse the query parameters
-		// for information about the GraphQL request
-		info := RequestInfo{}
-		q := r.URL.Query()
-		switch r.Method {
-		case "GET":
-			doc := q.Get("query")
-			if doc == "" {
-				w.WriteHeader(http.StatusBadRequest)
-				w.Write([]byte("No GraphQL query present"))
-				return
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{"GET", "POST"}
+	}
+	for _, method := range opts.Methods {
+		if method != "GET" && method != "POST" {
+			log.Panicf("GraphQL requests cannot be made with method '%s'", method)
+		}
+	}
+
+	if opts.StrictManifest && opts.Manifest == nil {
+		log.Panicf("StrictManifest requires a Manifest of the operations which may be executed")
+	}
+
+	if opts.FormatError == nil {
+		opts.FormatError = formatError
+	}
+
+	h := &handler{sch: sch, opts: opts}
+	if opts.GraphiQL {
+		h.graphiql = GraphiQLHandler("")
+	}
+	return h
+}
+
+// handler is the http.Handler returned by Schema.HandlerWithOptions.
+type handler struct {
+	sch      *Schema
+	opts     HandlerOptions
+	graphiql http.Handler // nil unless opts.GraphiQL is set
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	entry := RequestLog{Request: r}
+
+	h.serve(w, r, &entry)
+
+	if h.opts.Logger != nil {
+		entry.Duration = time.Since(start)
+		h.opts.Logger(entry)
+	}
+}
+
+// serve answers a request, recording its outcome in entry.
+func (h *handler) serve(w http.ResponseWriter, r *http.Request, entry *RequestLog) {
+	if h.graphiql != nil && wantsHTML(r) {
+		h.graphiql.ServeHTTP(w, r)
+		entry.Status = http.StatusOK
+		return
+	}
+
+	mediaType, ok := negotiate(r.Header.Get("Accept"))
+	if !ok {
+		// Errors are written as JSON regardless
+		h.writeError(w, entry, mediaTypeJSON, &httpError{http.StatusNotAcceptable,
+			"Responses can only be written as " + mediaTypeGraphQLResponse + " or " + mediaTypeJSON})
+		return
+	}
+
+	if !h.allowed(r.Method) {
+		w.Header().Set("Allow", strings.Join(h.opts.Methods, ", "))
+		h.writeError(w, entry, mediaType, &httpError{http.StatusMethodNotAllowed, "Method " + r.Method + " not allowed"})
+		return
+	}
+
+	if h.opts.CSRFProtection && r.Method == "POST" && !preflighted(r) {
+		h.writeError(w, entry, mediaType, &httpError{http.StatusBadRequest,
+			"POST requests must have a Content-Type which requires a CORS preflight, or set the " + csrfHeader + " header"})
+		return
+	}
+
+	if h.opts.MaxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.opts.MaxBodyBytes)
+	}
+
+	infos, batch, herr := parseRequest(r)
+	if herr != nil {
+		h.writeError(w, entry, mediaType, herr)
+		return
+	}
+
+	maxBatchSize := h.opts.MaxBatchSize
+	if maxBatchSize == 0 {
+		maxBatchSize = DefaultMaxBatchSize
+	}
+	if batch && len(infos) > maxBatchSize {
+		if maxBatchSize < 0 {
+			herr = &httpError{http.StatusBadRequest, "Batched requests are not supported"}
+		} else {
+			herr = &httpError{http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("Batch of %d operations is larger than the maximum of %d", len(infos), maxBatchSize)}
+		}
+		h.writeError(w, entry, mediaType, herr)
+		return
+	}
+
+	c := r.Context()
+	if h.opts.Context != nil {
+		c = h.opts.Context(r)
+	}
+	if h.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		c, cancel = context.WithTimeout(c, h.opts.Timeout)
+		defer cancel()
+	}
+
+	if !batch {
+		entry.Info = infos[0]
+		res := h.execute(c, r, infos[0], mediaType)
+		if res.status == http.StatusMethodNotAllowed {
+			// The request was a mutation sent by GET
+			w.Header().Set("Allow", "POST")
+		}
+		h.write(w, entry, mediaType, res)
+		return
+	}
+
+	// The operations of a batch are independent, so are scheduled by
+	// an Executor of the schema like the fields of a request, and share
+	// the timeout of the request
+	entry.Batch = infos
+	results := make([]operationResult, len(infos))
+	exec := h.sch.executor()
+	var wg sync.WaitGroup
+	for i, info := range infos {
+		i, info := i, info
+		wg.Add(1)
+		exec.Go(func() {
+			defer wg.Done()
+			results[i] = h.execute(c, r, info, mediaType)
+		})
+	}
+	wg.Wait()
+
+	h.writeBatch(w, entry, mediaType, results)
+}
+
+// An operationResult is the outcome of a single GraphQL request, or of
+// one operation of a batch.
+type operationResult struct {
+	status int
+	data   *ResponseNode
+	errors []error
+}
+
+// execute executes a single GraphQL request in the context c.
+func (h *handler) execute(c context.Context, r *http.Request, info *RequestInfo, mediaType string) operationResult {
+	// Responses which fail before execution, and so have no data,
+	// are client errors only for clients which understand the
+	// graphql-response media type.
+	requestErrorStatus := http.StatusOK
+	if mediaType == mediaTypeGraphQLResponse {
+		requestErrorStatus = http.StatusBadRequest
+	}
+
+	doc, status, err := h.document(info, requestErrorStatus)
+	if err != nil {
+		return operationResult{status: status, errors: []error{err}}
+	}
+
+	// GET requests must not have side effects, so that they cannot be
+	// triggered by a link or an image
+	if r.Method == "GET" && isMutation(doc, info.Operation) {
+		return errorResult(&httpError{http.StatusMethodNotAllowed, "Mutations cannot be executed by GET requests"})
+	}
+
+	ctx, _ := executeRequest(c, h.sch, doc, info.Operation, executeOptions{
+		variables:       info.Variables,
+		noIntrospection: h.opts.DisableIntrospection,
+	})
+
+	status = http.StatusOK
+	if ctx.Response == nil {
+		status = requestErrorStatus
+	}
+	return operationResult{status, ctx.Response, ctx.Errors}
+}
+
+// document returns the parsed document of a request, which is either
+// registered in the manifest, stored as a persisted query or given by
+// the request itself. requestErrorStatus is the status of a response to
+// a request which fails before execution, which is returned along with
+// an error that prevents the request from being executed.
+func (h *handler) document(info *RequestInfo, requestErrorStatus int) (*ast.Document, int, error) {
+	if info.DocumentID != "" || h.opts.StrictManifest {
+		return h.manifestDocument(info, requestErrorStatus)
+	}
+
+	register, status, err := h.persistedQuery(info, requestErrorStatus)
+	if err != nil {
+		return nil, status, err
+	}
+
+	if info.Query == "" {
+		return nil, http.StatusBadRequest, errors.New("No GraphQL query present")
+	}
+
+	if h.opts.MaxQueryLength > 0 && len(info.Query) > h.opts.MaxQueryLength {
+		return nil, http.StatusRequestEntityTooLarge,
+			fmt.Errorf("Query of %d bytes is longer than the maximum of %d", len(info.Query), h.opts.MaxQueryLength)
+	}
+
+	doc, err := ast.FromReader(strings.NewReader(info.Query))
+	if err != nil {
+		return nil, requestErrorStatus, err
+	}
+
+	if register != "" {
+		h.opts.PersistedQueries.Put(register, info.Query)
+	}
+	return &doc, 0, nil
+}
+
+func errorResult(err *httpError) operationResult {
+	return operationResult{status: err.status, errors: []error{err}}
+}
+
+// isMutation reports whether the active operation of a document is a
+// mutation. A document without the active operation is reported as an
+// error once it is executed.
+func isMutation(doc *ast.Document, active string) bool {
+	for _, def := range doc.Definitions {
+		if op, ok := def.(*ast.OperationDefinition); ok && op.Name == active {
+			return op.OpType == ast.MUTATION
+		}
+	}
+
+	return false
+}
+
+// csrfHeader is the header which a request may set to show that it was
+// preflighted, since setting it requires one.
+const csrfHeader = "GraphQL-Require-Preflight"
+
+// preflighted reports whether a browser would have sent a CORS
+// preflight request before a request from another origin.
+func preflighted(r *http.Request) bool {
+	if r.Header.Get(csrfHeader) != "" {
+		return true
+	}
+
+	t, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		// A request without a Content-Type needs no preflight
+		return false
+	}
+
+	switch t {
+	case "application/x-www-form-urlencoded", "multipart/form-data", "text/plain":
+		return false
+	}
+	return true
+}
+
+func (h *handler) allowed(method string) bool {
+	for _, m := range h.opts.Methods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeError writes a response without data, reporting a single error.
+func (h *handler) writeError(w http.ResponseWriter, entry *RequestLog, mediaType string, err *httpError) {
+	h.write(w, entry, mediaType, errorResult(err))
+}
+
+func (h *handler) write(w http.ResponseWriter, entry *RequestLog, mediaType string, res operationResult) {
+	h.writeJSON(w, entry, mediaType, res.status, h.format(res), res.errors)
+}
+
+// writeBatch writes the results of a batch as a list, in the order of
+// its operations.
+func (h *handler) writeBatch(w http.ResponseWriter, entry *RequestLog, mediaType string, results []operationResult) {
+	body := make([]response, len(results))
+	var errs []error
+	for i, res := range results {
+		body[i] = h.format(res)
+		errs = append(errs, res.errors...)
+	}
+	h.writeJSON(w, entry, mediaType, http.StatusOK, body, errs)
+}
+
+func (h *handler) format(res operationResult) response {
+	body := response{Data: res.data}
+	for _, err := range res.errors {
+		body.Errors = append(body.Errors, h.opts.FormatError(err))
+	}
+	return body
+}
+
+func (h *handler) writeJSON(w http.ResponseWriter, entry *RequestLog, mediaType string, status int, body interface{}, errs []error) {
+	res, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("Could not encode GraphQL response: %s", err)
+		status = http.StatusInternalServerError
+		errs = append(errs, err)
+		res, _ = json.Marshal(response{Errors: []interface{}{formatError(err)}})
+	}
+
+	entry.Status = status
+	entry.Errors = errs
+
+	w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(res)
+}
+
+// parseRequest reads the parameters of a GraphQL request, or of each
+// operation of a batch, which is given as a JSON list in the body of a
+// POST request.
+func parseRequest(r *http.Request) (infos []*RequestInfo, batch bool, herr *httpError) {
+	info := &RequestInfo{}
+	q := r.URL.Query()
+
+	switch r.Method {
+	case "GET":
+		info.Query = q.Get("query")
+		if err := parseParams(info, q); err != nil {
+			return nil, false, err
+		}
+
+	case "POST":
+		contentType := r.Header.Get("Content-Type")
+		if contentType == "" {
+			return nil, false, &httpError{http.StatusUnsupportedMediaType, "Missing Content-Type"}
+		}
+
+		t, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return nil, false, &httpError{http.StatusBadRequest, "Invalid Content-Type: " + err.Error()}
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if tooLarge := (*http.MaxBytesError)(nil); errors.As(err, &tooLarge) {
+			return nil, false, &httpError{http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("Request body is larger than the maximum of %d bytes", tooLarge.Limit)}
+		} else if err != nil {
+			return nil, false, &httpError{http.StatusBadRequest, "Could not read request body: " + err.Error()}
+		}
+
+		switch t {
+		case mediaTypeJSON:
+			if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+				if err := unmarshalJSON(body, &infos); err != nil {
+					return nil, false, &httpError{http.StatusBadRequest, "Batched request body must be a list of JSON objects: " + err.Error()}
+				}
+				if len(infos) == 0 {
+					return nil, false, &httpError{http.StatusBadRequest, "Batched request contains no operations"}
+				}
+				for i, info := range infos {
+					if info == nil {
+						return nil, false, &httpError{http.StatusBadRequest, fmt.Sprintf("Operation %d of batched request is null", i)}
+					}
+				}
+				return infos, true, nil
 			}
 
-			info.Document = strings.NewReader(doc)
+			if err := unmarshalJSON(body, info); err != nil {
+				return nil, false, &httpError{http.StatusBadRequest, "Request body must be a JSON object: " + err.Error()}
+			}
 
-		case "POST":
-			info.Document = r.Body
+		case mediaTypeGraphQL:
+			info.Query = string(body)
+			if err := parseParams(info, q); err != nil {
+				return nil, false, err
+			}
 
 		default:
-			w.Header().Add("Allow", "GET, POST")
-			w.WriteHeader(http.StatusMethodNotAllowed)
+			return nil, false, &httpError{http.StatusUnsupportedMediaType, "Unsupported Content-Type '" + t + "'"}
 		}
 
-		info.Variables = q.Get("variables")
-		info.Operation = q.Get("operationName")
+	default:
+		return nil, false, &httpError{http.StatusMethodNotAllowed, "Method " + r.Method + " not allowed"}
+	}
 
-		doc, err := ast.FromReader(info.Document)
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte(err.Error()))
-			return
+	return []*RequestInfo{info}, false, nil
+}
+
+// parseParams reads the parameters other than the document from the
+// URL of a request.
+func parseParams(info *RequestInfo, q url.Values) *httpError {
+	info.Operation = q.Get("operationName")
+	info.DocumentID = q.Get("documentId")
+
+	for key, out := range map[string]*map[string]interface{}{
+		"variables":  &info.Variables,
+		"extensions": &info.Extensions,
+	} {
+		if v := q.Get(key); v != "" {
+			if err := unmarshalJSON([]byte(v), out); err != nil {
+				return &httpError{http.StatusBadRequest, "Parameter '" + key + "' must be a JSON object: " + err.Error()}
+			}
 		}
+	}
+
+	return nil
+}
+
+// negotiate chooses the media type of the response from the Accept
+// header of a request, preferring application/graphql-response+json
+// when the client explicitly accepts both equally.
+func negotiate(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return mediaTypeJSON, true
+	}
 
-		ctx, err := Execute(sch, &doc, info.Operation)
+	type candidate struct {
+		mediaType string
+		q         float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(accept, ",") {
+		t, params, err := mime.ParseMediaType(strings.TrimSpace(part))
 		if err != nil {
-			log.Printf("%#v", ctx.Errors)
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte(err.Error()))
-			return
+			continue
 		}
 
-		res, err := ctx.Response.MarshalJSON()
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte(err.Error()))
-			return
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
 		}
 
-		w.Write(res)
+		switch t {
+		case mediaTypeGraphQLResponse, mediaTypeJSON:
+			candidates = append(candidates, candidate{t, q})
+		case "*/*", "application/*":
+			// Clients which accept anything are not assumed to
+			// understand the newer media type
+			candidates = append(candidates, candidate{mediaTypeJSON, q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].q != candidates[j].q {
+			return candidates[i].q > candidates[j].q
+		}
+		return candidates[i].mediaType == mediaTypeGraphQLResponse && candidates[j].mediaType != mediaTypeGraphQLResponse
 	})
+	return candidates[0].mediaType, true
 }
 
 func Handler() http.Handler {
@@ -0,0 +1,502 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// expiringContext is a context whose deadline is exceeded once done is
+// closed, so that a test decides when its request times out.
+type expiringContext struct {
+	context.Context
+	done chan struct{}
+}
+
+func (c *expiringContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *expiringContext) Err() error {
+	select {
+	case <-c.done:
+		return context.DeadlineExceeded
+	default:
+		return nil
+	}
+}
+
+func TestHandlerTimeout(t *testing.T) {
+	c := &expiringContext{Context: context.Background(), done: make(chan struct{})}
+
+	sch := newTestSchema(t, bankSchema, "Query", "Mutation")
+	sch.AddResolveFunc("Account", func(r *ResponseNode) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("Expected the request to have a deadline")
+		}
+		r.Set("balance", 10)
+
+		// The request times out while the account is resolved
+		close(c.done)
+		<-r.Context().Done()
+	})
+	sch.AddResolveFunc("Owner", func(r *ResponseNode) {
+		t.Error("Owner resolved after the request timed out")
+	})
+
+	h := sch.HandlerWithOptions(HandlerOptions{
+		Timeout: time.Hour,
+		Context: func(*http.Request) context.Context { return c },
+	})
+
+	q := url.Values{"query": {"{ account { balance owner { name } } }"}}
+	req := httptest.NewRequest("GET", "/graphql?"+q.Encode(), nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	expect := `{"data":{"account":{"balance":10,"owner":null}},"errors":[{"message":"context deadline exceeded"}]}`
+	if body := w.Body.String(); body != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, body)
+	}
+}
+
+type handlerTest struct {
+	method      string
+	target      string
+	contentType string
+	accept      string
+	body        string
+
+	status       int
+	responseType string // The media type of the response, without parameters
+	response     string
+}
+
+var handlerTests = map[string]handlerTest{
+	"Get": {"GET", "/graphql?query=" + url.QueryEscape("{ account { balance } }"), "", "", "",
+		http.StatusOK, "application/json", `{"data":{"account":{"balance":10}}}`},
+	"PostJSON": {"POST", "/graphql", "application/json; charset=utf-8", "",
+		`{"query": "query Q($full: Boolean!) { account @include(if: $full) { balance } }", "variables": {"full": true}, "operationName": "Q", "extensions": {}}`,
+		http.StatusOK, "application/json", `{"data":{"account":{"balance":10}}}`},
+	"PostGraphQL": {"POST", "/graphql?operationName=Balance", "application/graphql", "",
+		"query Balance { account { balance } }",
+		http.StatusOK, "application/json", `{"data":{"account":{"balance":10}}}`},
+	"GraphQLResponse": {"GET", "/graphql?query=" + url.QueryEscape("{ account { balance } }"), "",
+		"application/graphql-response+json, application/json;q=0.9", "",
+		http.StatusOK, "application/graphql-response+json", `{"data":{"account":{"balance":10}}}`},
+	"PreferJSON": {"GET", "/graphql?query=" + url.QueryEscape("{ account { balance } }"), "",
+		"application/graphql-response+json;q=0.5, application/json", "",
+		http.StatusOK, "application/json", `{"data":{"account":{"balance":10}}}`},
+	"Wildcard": {"GET", "/graphql?query=" + url.QueryEscape("{ account { balance } }"), "", "*/*", "",
+		http.StatusOK, "application/json", `{"data":{"account":{"balance":10}}}`},
+
+	"SyntaxError": {"GET", "/graphql?query=" + url.QueryEscape("{ account {"), "", "", "",
+		http.StatusOK, "application/json", ""},
+	"SyntaxErrorGraphQLResponse": {"GET", "/graphql?query=" + url.QueryEscape("{ account {"), "",
+		"application/graphql-response+json", "",
+		http.StatusBadRequest, "application/graphql-response+json", ""},
+	"UnterminatedString": {"GET", "/graphql?query=" + url.QueryEscape(`{ a(x: "abc`), "",
+		"application/graphql-response+json", "",
+		http.StatusBadRequest, "application/graphql-response+json", `{"errors":[{"message":"Unterminated string literal"}]}`},
+	"UnknownOperation": {"GET", "/graphql?operationName=Other&query=" + url.QueryEscape("{ account { balance } }"), "",
+		"application/graphql-response+json", "",
+		http.StatusBadRequest, "application/graphql-response+json",
+		`{"errors":[{"message":"Expecting definition named 'Other', but none found"}]}`},
+	"UnknownRootField": {"GET", "/graphql?query=" + url.QueryEscape("{ account { balance } bogus }"), "", "", "",
+		http.StatusOK, "application/json", `{"errors":[{"message":"Type has no field named 'bogus'"}]}`},
+	"UnknownRootFieldGraphQLResponse": {"GET", "/graphql?query=" + url.QueryEscape("{ account { balance } bogus }"), "",
+		"application/graphql-response+json", "",
+		http.StatusBadRequest, "application/graphql-response+json", `{"errors":[{"message":"Type has no field named 'bogus'"}]}`},
+
+	"MissingQuery": {"GET", "/graphql", "", "", "",
+		http.StatusBadRequest, "application/json", `{"errors":[{"message":"No GraphQL query present"}]}`},
+	"InvalidJSON": {"POST", "/graphql", "application/json", "", `{"query": `,
+		http.StatusBadRequest, "application/json", ""},
+	"InvalidParameter": {"POST", "/graphql", "application/json", "", `{"query": "{ account { balance } }", "variables": []}`,
+		http.StatusBadRequest, "application/json", ""},
+	"MissingContentType": {"POST", "/graphql", "", "", "{ account { balance } }",
+		http.StatusUnsupportedMediaType, "application/json", ""},
+	"UnsupportedContentType": {"POST", "/graphql", "text/plain", "", "{ account { balance } }",
+		http.StatusUnsupportedMediaType, "application/json", ""},
+	"NotAcceptable": {"GET", "/graphql?query=" + url.QueryEscape("{ account { balance } }"), "", "text/html", "",
+		http.StatusNotAcceptable, "application/json", ""},
+	"MethodNotAllowed": {"PUT", "/graphql", "application/json", "", `{"query": "{ account { balance } }"}`,
+		http.StatusMethodNotAllowed, "application/json", `{"errors":[{"message":"Method PUT not allowed"}]}`},
+}
+
+func TestHandler(t *testing.T) {
+	sch := newTestSchema(t, bankSchema, "Query", "")
+	sch.AddResolveFunc("Account", func(r *ResponseNode) {
+		r.Set("balance", 10)
+	})
+	h := sch.Handler()
+
+	for name, test := range handlerTests {
+		req := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+		if test.contentType != "" {
+			req.Header.Set("Content-Type", test.contentType)
+		}
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if w.Code != test.status {
+			t.Errorf("%s: Expected status %d, got %d: %s", name, test.status, w.Code, w.Body)
+		}
+
+		if mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type")); mediaType != test.responseType {
+			t.Errorf("%s: Expected content type '%s', got '%s'", name, test.responseType, w.Header().Get("Content-Type"))
+		}
+
+		var body struct {
+			Errors []struct{ Message string }
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Errorf("%s: Response is not JSON: %s", name, w.Body)
+		} else if test.status != http.StatusOK && len(body.Errors) == 0 {
+			t.Errorf("%s: Expected an error, got %s", name, w.Body)
+		}
+
+		if test.response != "" && w.Body.String() != test.response {
+			t.Errorf("%s: Expected '%s', got '%s'", name, test.response, w.Body)
+		}
+	}
+
+	req := httptest.NewRequest("DELETE", "/graphql", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if allow := w.Header().Get("Allow"); allow != "GET, POST" {
+		t.Errorf("Expected methods to be allowed, got '%s'", allow)
+	}
+}
+
+type userKey struct{}
+
+func TestHandlerOptions(t *testing.T) {
+	sch := newTestSchema(t, bankSchema, "Query", "")
+	sch.AddResolveFunc("Account", func(r *ResponseNode) {
+		r.Set("balance", r.Context().Value(userKey{}))
+	})
+
+	var logged []RequestLog
+	h := sch.HandlerWithOptions(HandlerOptions{
+		MaxBodyBytes:         64,
+		MaxQueryLength:       40,
+		Methods:              []string{"POST"},
+		DisableIntrospection: true,
+		FormatError: func(err error) interface{} {
+			return map[string]interface{}{"message": err.Error(), "extensions": map[string]string{"code": "ERROR"}}
+		},
+		Logger: func(entry RequestLog) {
+			logged = append(logged, entry)
+		},
+		Context: func(r *http.Request) context.Context {
+			return context.WithValue(r.Context(), userKey{}, 42)
+		},
+	})
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/graphql")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("{ account { balance } }")
+	if expect := `{"data":{"account":{"balance":42}}}`; w.Body.String() != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, w.Body)
+	}
+
+	w = post("{ __schema { queryType { name } } }")
+	if expect := `{"errors":[{"extensions":{"code":"ERROR"},"message":"Type has no field named '__schema'"}]}`; w.Body.String() != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, w.Body)
+	}
+
+	w = post("{ account { balance owner { name } bank { name } } }")
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status %d for a long query, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+
+	w = post("{ account { balance } }" + strings.Repeat(" ", 64))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status %d for a large body, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape("{ account { balance } }"), nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "POST" {
+		t.Errorf("Expected GET to be rejected, got status %d allowing '%s'", w.Code, w.Header().Get("Allow"))
+	}
+
+	if len(logged) != 5 {
+		t.Fatalf("Expected 5 requests to be logged, got %d", len(logged))
+	}
+	if entry := logged[0]; entry.Status != http.StatusOK || entry.Info.Query != "{ account { balance } }" || len(entry.Errors) != 0 {
+		t.Errorf("Unexpected log entry %+v", entry)
+	}
+	if entry := logged[4]; entry.Status != http.StatusMethodNotAllowed || entry.Info != nil || len(entry.Errors) != 1 {
+		t.Errorf("Unexpected log entry %+v", entry)
+	}
+}
+
+func TestHandlerInvalidMethod(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+
+	sch := newTestSchema(t, bankSchema, "Query", "")
+	sch.HandlerWithOptions(HandlerOptions{Methods: []string{"PUT"}})
+}
+
+func TestHandlerGetMutation(t *testing.T) {
+	sch := newTestSchema(t, bankSchema, "Query", "Mutation")
+	sch.AddResolveFunc("Account", func(r *ResponseNode) {
+		r.Set("balance", 10)
+	})
+	sch.AddResolveFunc("Mutation", func(r *ResponseNode) {
+		t.Error("Mutation executed by a GET request")
+	})
+	h := sch.Handler()
+
+	doc := "query Balance { account { balance } } mutation Deposit { deposit(amount: 5) { balance } }"
+	for operation, status := range map[string]int{
+		"Balance": http.StatusOK,
+		"Deposit": http.StatusMethodNotAllowed,
+	} {
+		q := url.Values{"query": {doc}, "operationName": {operation}}
+		req := httptest.NewRequest("GET", "/graphql?"+q.Encode(), nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if w.Code != status {
+			t.Errorf("%s: Expected status %d, got %d: %s", operation, status, w.Code, w.Body)
+		}
+		if status == http.StatusMethodNotAllowed && w.Header().Get("Allow") != "POST" {
+			t.Errorf("%s: Expected POST to be allowed, got '%s'", operation, w.Header().Get("Allow"))
+		}
+	}
+}
+
+func TestHandlerCSRFProtection(t *testing.T) {
+	sch := newTestSchema(t, bankSchema, "Query", "")
+	sch.AddResolveFunc("Account", func(r *ResponseNode) {
+		r.Set("balance", 10)
+	})
+	h := sch.HandlerWithOptions(HandlerOptions{CSRFProtection: true})
+
+	tests := []struct {
+		contentType string
+		header      string
+		status      int
+	}{
+		{"application/json", "", http.StatusOK},
+		{"text/plain", "", http.StatusBadRequest},
+		{"text/plain; charset=utf-8", "", http.StatusBadRequest},
+		{"application/x-www-form-urlencoded", "", http.StatusBadRequest},
+		{"", "", http.StatusBadRequest},
+		{"application/json", "1", http.StatusOK},
+		{"text/plain", "1", http.StatusUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{ account { balance } }"}`))
+		if test.contentType != "" {
+			req.Header.Set("Content-Type", test.contentType)
+		}
+		if test.header != "" {
+			req.Header.Set("GraphQL-Require-Preflight", test.header)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != test.status {
+			t.Errorf("%s, %q: Expected status %d, got %d: %s", test.contentType, test.header, test.status, w.Code, w.Body)
+		}
+	}
+
+	// GET requests can only execute queries, so need no protection
+	req := httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape("{ account { balance } }"), nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected GET to be allowed, got status %d", w.Code)
+	}
+}
+
+func TestHandlerGraphiQL(t *testing.T) {
+	sch := newTestSchema(t, bankSchema, "Query", "")
+	sch.AddResolveFunc("Account", func(r *ResponseNode) {
+		r.Set("balance", 10)
+	})
+
+	browser := "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	tests := []struct {
+		opts        HandlerOptions
+		method      string
+		accept      string
+		contentType string
+	}{
+		{HandlerOptions{GraphiQL: true}, "GET", browser, "text/html"},
+		{HandlerOptions{GraphiQL: true}, "GET", "application/json", mediaTypeJSON},
+		{HandlerOptions{GraphiQL: true}, "POST", browser, mediaTypeJSON},
+		{HandlerOptions{}, "GET", browser, mediaTypeJSON},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/graphql?query="+url.QueryEscape("{ account { balance } }"), nil)
+		if test.method == "POST" {
+			req = httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{ account { balance } }"}`))
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Accept", test.accept)
+
+		w := httptest.NewRecorder()
+		sch.HandlerWithOptions(test.opts).ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("%s %s: Expected status %d, got %d", test.method, test.accept, http.StatusOK, w.Code)
+		}
+		if actual := w.Header().Get("Content-Type"); !strings.HasPrefix(actual, test.contentType+";") {
+			t.Errorf("%s %s: Expected Content-Type '%s', got '%s'", test.method, test.accept, test.contentType, actual)
+		}
+	}
+}
+
+func TestGraphiQLHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	GraphiQLHandler("/api/graphql").ServeHTTP(w, httptest.NewRequest("GET", "/graphiql", nil))
+
+	body := w.Body.String()
+	if !strings.Contains(body, `var endpoint = "/api/graphql";`) {
+		t.Errorf("Expected the page to query the given endpoint, got '%s'", body)
+	}
+	if !strings.Contains(body, "fetchGraphQL") || strings.Contains(body, "<script src=") {
+		t.Error("Expected the script to be inlined")
+	}
+}
+
+func TestHandlerBatch(t *testing.T) {
+	sch := newTestSchema(t, bankSchema, "Query", "Mutation")
+
+	// Each operation waits for the others, so the batch only completes
+	// if they are executed concurrently
+	var started sync.WaitGroup
+	started.Add(2)
+	sch.AddResolveFunc("Account", func(r *ResponseNode) {
+		started.Done()
+		all := make(chan struct{})
+		go func() {
+			started.Wait()
+			close(all)
+		}()
+
+		select {
+		case <-all:
+		case <-time.After(time.Second):
+			t.Error("Operations of a batch were not executed concurrently")
+		}
+
+		amount, ok := r.Args.Get("amount")
+		if !ok {
+			amount = 10
+		}
+		r.Set("balance", amount)
+	})
+
+	var logged RequestLog
+	h := sch.HandlerWithOptions(HandlerOptions{
+		MaxBatchSize: 3,
+		Logger:       func(entry RequestLog) { logged = entry },
+	})
+
+	body := `[
+		{"query": "{ account { balance } }"},
+		{"query": "mutation Deposit($amount: Int!) { deposit(amount: $amount) { balance } }", "variables": {"amount": 25}, "operationName": "Deposit"},
+		{"query": "{ account { "}
+	]`
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var res []struct {
+		Data   map[string]interface{}
+		Errors []struct{ Message string }
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("Expected a list of results, got '%s'", w.Body)
+	}
+	if len(res) != 3 {
+		t.Fatalf("Expected 3 results, got '%s'", w.Body)
+	}
+
+	if balance := res[0].Data["account"]; !reflect.DeepEqual(balance, map[string]interface{}{"balance": 10.0}) {
+		t.Errorf("Unexpected result of first operation: %v", balance)
+	}
+	if balance := res[1].Data["deposit"]; !reflect.DeepEqual(balance, map[string]interface{}{"balance": 25.0}) {
+		t.Errorf("Unexpected result of second operation: %v", balance)
+	}
+	if res[2].Data != nil || len(res[2].Errors) != 1 {
+		t.Errorf("Expected a syntax error for the third operation, got %v", res[2])
+	}
+
+	if logged.Info != nil || len(logged.Batch) != 3 || len(logged.Errors) != 1 {
+		t.Errorf("Batch was not logged: %+v", logged)
+	}
+
+	for body, status := range map[string]int{
+		`[]`:                                   http.StatusBadRequest,
+		`[null]`:                               http.StatusBadRequest,
+		`[{"query": "{ account { balance } }"`: http.StatusBadRequest,
+		`[{}, {}, {}, {}]`:                     http.StatusRequestEntityTooLarge,
+	} {
+		req := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if w.Code != status {
+			t.Errorf("%s: Expected status %d, got %d", body, status, w.Code)
+		}
+	}
+
+	req = httptest.NewRequest("POST", "/graphql", strings.NewReader(`[{"query": "{ account { balance } }"}]`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	sch.HandlerWithOptions(HandlerOptions{MaxBatchSize: -1}).ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected batching to be disabled, got status %d", w.Code)
+	}
+
+	body = "[" + strings.Repeat(`{"query": "{ account { balance } }"}, `, DefaultMaxBatchSize) + "{}]"
+	req = httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	sch.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected the batch size to be limited by default, got status %d", w.Code)
+	}
+}
@@ -1 +1,372 @@
 package schema
+
+import (
+	_ "embed"
+	"log"
+	"sort"
+	"strings"
+
+	"dylanmackenzie.com/graphql/ast"
+)
+
+// The types used to describe a schema, as given in section 4.2 of
+// Introspection.
+//
+//go:embed introspection.schema
+var introspectionSchema string
+
+// The fields which may be selected without being declared by a type.
+// __schema and __type are only valid on the root of a query, while
+// __typename is valid on every object. Directives are described by the
+// arguments of the field of the same name.
+const metaSchema = `
+type __Meta {
+  __schema: __Schema!
+  __type(name: String!): __Type
+  __typename: String!
+}
+
+type __Directives {
+  skip(if: Boolean!): Boolean
+  include(if: Boolean!): Boolean
+}
+`
+
+// The key in the result map of an introspection object under which the
+// value it describes is stored.
+const introspectedKey = "__introspected"
+
+// addIntrospection adds the introspection types and their resolvers to
+// the schema.
+func (sch *Schema) addIntrospection() {
+	doc, err := ast.FromReader(strings.NewReader(introspectionSchema))
+	if err != nil {
+		log.Panicf("Invalid introspection schema: %s", err)
+	}
+
+	for _, def := range doc.Definitions {
+		sch.addType(def.(ast.TypeDefinition))
+	}
+
+	doc, err = ast.FromReader(strings.NewReader(metaSchema))
+	if err != nil {
+		log.Panicf("Invalid introspection schema: %s", err)
+	}
+
+	sch.meta = doc.Definitions[0].(*ast.ObjectDefinition)
+	sch.directives = doc.Definitions[1].(*ast.ObjectDefinition)
+
+	sch.resolvers["__Schema"] = ResolveFunc(sch.introspectSchema)
+	sch.resolvers["__Type"] = ResolveFunc(sch.introspectType)
+	sch.resolvers["__Field"] = ResolveFunc(sch.introspectField)
+	sch.resolvers["__InputValue"] = ResolveFunc(sch.introspectInputValue)
+	sch.resolvers["__EnumValue"] = ResolveFunc(sch.introspectEnumValue)
+	sch.resolvers["__Directive"] = ResolveFunc(sch.introspectDirective)
+}
+
+// finalizeIntrospection caches the definitions of the types of the
+// introspection fields, as Finalize does for the fields of objects.
+func (sch *Schema) finalizeIntrospection() {
+	for _, obj := range []*ast.ObjectDefinition{sch.meta, sch.directives} {
+		for i, field := range obj.Fields {
+			obj.Fields[i].Definition = sch.definition(ast.GetBaseType(field.Type))
+		}
+	}
+}
+
+// field finds the declaration of a field selected on a value of type
+// def, including the introspection fields which are not declared by its
+// type. root is whether the field is selected by the operation itself.
+func (ctx *executionContext) field(def ast.AbstractTypeDefinition, root bool, name string) (*ast.TypeField, bool) {
+	switch name {
+	case "__typename":
+		return ctx.Schema.meta.Field(name)
+	case "__schema", "__type":
+		if root && ctx.Root == ctx.Schema.QueryRoot && !ctx.noIntrospection {
+			return ctx.Schema.meta.Field(name)
+		}
+	}
+
+	return def.Field(name)
+}
+
+// A typeRef is the type described by a __Type. Lists and non-null
+// types wrap the type they modify.
+type typeRef struct {
+	kind   string // LIST or NON_NULL for wrappers, empty otherwise.
+	ofType *typeRef
+	def    ast.TypeDefinition
+	input  *ast.InputObjectType // Set for anonymous input objects.
+}
+
+// typeRef returns the typeRef for a type as used by a field or argument.
+func (sch *Schema) typeRef(desc ast.TypeDescriptor) *typeRef {
+	var t *typeRef
+	switch d := desc.(type) {
+	case *ast.ListType:
+		t = &typeRef{kind: "LIST", ofType: sch.typeRef(d.OfType)}
+	case *ast.InputObjectType:
+		t = &typeRef{input: d}
+	default:
+		t = &typeRef{def: sch.types[desc.Name()]}
+	}
+
+	if !desc.Nullable() {
+		t = &typeRef{kind: "NON_NULL", ofType: t}
+	}
+
+	return t
+}
+
+func (t *typeRef) typeKind() string {
+	if t.kind != "" {
+		return t.kind
+	}
+
+	switch t.def.(type) {
+	case *ast.ScalarDefinition:
+		return "SCALAR"
+	case *ast.ObjectDefinition:
+		return "OBJECT"
+	case *ast.InterfaceDefinition:
+		return "INTERFACE"
+	case *ast.UnionDefinition:
+		return "UNION"
+	case *ast.EnumDefinition:
+		return "ENUM"
+	case *ast.InputObjectDefinition:
+		return "INPUT_OBJECT"
+	}
+
+	return "INPUT_OBJECT"
+}
+
+// An inputValue is the argument or input object field described by an
+// __InputValue.
+type inputValue struct {
+	name    string
+	typ     ast.TypeDescriptor
+	initial ast.Value
+}
+
+func setTypeRef(r *ResponseNode, t *typeRef) {
+	r.Set(introspectedKey, t)
+	r.Set("kind", t.typeKind())
+	r.Set("description", nil)
+	if t.def != nil {
+		r.Set("name", t.def.TypeName())
+	} else {
+		r.Set("name", nil)
+	}
+
+	if scalar, ok := t.def.(*ast.ScalarDefinition); ok && scalar.SpecifiedBy != "" {
+		r.Set("specifiedByURL", scalar.SpecifiedBy)
+	} else {
+		r.Set("specifiedByURL", nil)
+	}
+}
+
+func setInputValue(r *ResponseNode, v *inputValue) {
+	r.Set(introspectedKey, v)
+	r.Set("name", v.name)
+	r.Set("description", nil)
+	if v.initial != nil {
+		r.Set("defaultValue", ast.FormatValue(v.initial))
+	} else {
+		r.Set("defaultValue", nil)
+	}
+}
+
+// introspected returns the value described by the parent of r.
+func introspected(r *ResponseNode) interface{} {
+	v, _ := r.Parent().Get(introspectedKey)
+	return v
+}
+
+func (sch *Schema) introspectSchema(r *ResponseNode) {}
+
+func (sch *Schema) introspectType(r *ResponseNode) {
+	switch r.Name() {
+	case "types":
+		names := make([]string, 0, len(sch.types))
+		for name := range sch.types {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			setTypeRef(r.Append(), &typeRef{def: sch.types[name]})
+		}
+
+	case "queryType":
+		setTypeRef(r, &typeRef{def: sch.QueryRoot})
+
+	case "mutationType":
+		if sch.MutationRoot == nil {
+			r.Null(true)
+			return
+		}
+		setTypeRef(r, &typeRef{def: sch.MutationRoot})
+
+	case "__type":
+		name, _ := r.Args.GetAsString("name")
+		def, ok := sch.types[name]
+		if !ok {
+			r.Null(true)
+			return
+		}
+		setTypeRef(r, &typeRef{def: def})
+
+	case "type":
+		switch v := introspected(r).(type) {
+		case *ast.TypeField:
+			setTypeRef(r, sch.typeRef(v.Type))
+		case *inputValue:
+			setTypeRef(r, sch.typeRef(v.typ))
+		}
+
+	case "ofType":
+		t := introspected(r).(*typeRef)
+		if t.ofType == nil {
+			r.Null(true)
+			return
+		}
+		setTypeRef(r, t.ofType)
+
+	case "interfaces":
+		obj, ok := introspected(r).(*typeRef).def.(*ast.ObjectDefinition)
+		if !ok {
+			r.Null(true)
+			return
+		}
+
+		for _, name := range obj.Implements {
+			setTypeRef(r.Append(), &typeRef{def: sch.types[name]})
+		}
+
+	case "possibleTypes":
+		switch t := introspected(r).(*typeRef).def.(type) {
+		case *ast.UnionDefinition:
+			for _, member := range t.Members {
+				setTypeRef(r.Append(), &typeRef{def: sch.types[member.Name()]})
+			}
+
+		case *ast.InterfaceDefinition:
+			for _, obj := range sch.implementations(t.Name) {
+				setTypeRef(r.Append(), &typeRef{def: obj})
+			}
+
+		default:
+			r.Null(true)
+		}
+	}
+}
+
+// implementations returns the objects implementing the named interface,
+// sorted by name.
+func (sch *Schema) implementations(iface string) []*ast.ObjectDefinition {
+	objs := make([]*ast.ObjectDefinition, 0)
+	for _, def := range sch.types {
+		obj, ok := def.(*ast.ObjectDefinition)
+		if !ok {
+			continue
+		}
+
+		for _, name := range obj.Implements {
+			if name == iface {
+				objs = append(objs, obj)
+			}
+		}
+	}
+
+	sort.Slice(objs, func(i, j int) bool { return objs[i].Name < objs[j].Name })
+	return objs
+}
+
+func (sch *Schema) introspectField(r *ResponseNode) {
+	var fields ast.TypeFields
+	switch t := introspected(r).(*typeRef).def.(type) {
+	case *ast.ObjectDefinition:
+		fields = t.Fields
+	case *ast.InterfaceDefinition:
+		fields = t.Fields
+	default:
+		r.Null(true)
+		return
+	}
+
+	for i := range fields {
+		item := r.Append()
+		item.Set(introspectedKey, &fields[i])
+		item.Set("name", fields[i].Name)
+		item.Set("description", nil)
+		item.Set("isDeprecated", false)
+		item.Set("deprecationReason", nil)
+	}
+}
+
+func (sch *Schema) introspectInputValue(r *ResponseNode) {
+	switch v := introspected(r).(type) {
+	case *ast.TypeField:
+		for _, arg := range v.Arguments {
+			setInputValue(r.Append(), &inputValue{arg.Key, arg.Type, arg.Default})
+		}
+
+	case *typeRef:
+		if obj, ok := v.def.(*ast.InputObjectDefinition); ok {
+			for _, field := range obj.Fields {
+				setInputValue(r.Append(), &inputValue{field.Key, field.Type, field.Default})
+			}
+			return
+		}
+
+		if v.input == nil {
+			r.Null(true)
+			return
+		}
+
+		keys := make([]string, 0, len(v.input.Fields))
+		for key := range v.input.Fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			setInputValue(r.Append(), &inputValue{key, v.input.Fields[key], nil})
+		}
+	}
+}
+
+func (sch *Schema) introspectEnumValue(r *ResponseNode) {
+	enum, ok := introspected(r).(*typeRef).def.(*ast.EnumDefinition)
+	if !ok {
+		r.Null(true)
+		return
+	}
+
+	names := make([]string, 0, len(enum.Values))
+	for name := range enum.Values {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return enum.Values[names[i]] < enum.Values[names[j]] })
+
+	for _, name := range names {
+		item := r.Append()
+		item.Set("name", name)
+		item.Set("description", nil)
+		item.Set("isDeprecated", false)
+		item.Set("deprecationReason", nil)
+	}
+}
+
+func (sch *Schema) introspectDirective(r *ResponseNode) {
+	for i, directive := range sch.directives.Fields {
+		item := r.Append()
+		item.Set(introspectedKey, &sch.directives.Fields[i])
+		item.Set("name", directive.Name)
+		item.Set("description", nil)
+		item.Set("onOperation", false)
+		item.Set("onFragment", true)
+		item.Set("onField", true)
+	}
+}
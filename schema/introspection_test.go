@@ -0,0 +1,112 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"dylanmackenzie.com/graphql/ast"
+)
+
+var dogSchema = `
+type Dog {
+  name: String
+  isHouseTrained(atOtherHomes: Boolean = false): Boolean
+}
+
+type Query {
+  dog(name: String = "Fido"): Dog
+}
+`
+
+var dogResults = map[string]string{
+	`{ __typename __schema { queryType { name } mutationType { name } } }`: `{"__typename":"Query",` +
+		`"__schema":{"queryType":{"name":"Query"},"mutationType":null}}`,
+	`{ __type(name: "Dog") { kind name fields { name args { name defaultValue type { kind name ofType { name } } } } } }`: `{"__type":{"kind":"OBJECT","name":"Dog","fields":[` +
+		`{"name":"name","args":[]},` +
+		`{"name":"isHouseTrained","args":[{"name":"atOtherHomes","defaultValue":"false",` +
+		`"type":{"kind":"SCALAR","name":"Boolean","ofType":null}}]}]}}`,
+	`{ dog { name } }`:              `{"dog":{"name":"Fido"}}`,
+	`{ dog(name: "Rex") { name } }`: `{"dog":{"name":"Rex"}}`,
+}
+
+func resolveDog(r *ResponseNode) {
+	name, _ := r.Args.GetAsString("name")
+	r.Set("name", name)
+	r.Set("isHouseTrained", true)
+}
+
+func TestArgumentDefault(t *testing.T) {
+	sch := newTestSchema(t, dogSchema, "Query", "")
+	dog, _ := sch.types["Dog"].(*ast.ObjectDefinition).Field("isHouseTrained")
+	if len(dog.Arguments) != 1 || dog.Arguments[0].Default != ast.BooleanValue(false) {
+		t.Fatalf("Expected default value 'false', got %v", dog.Arguments)
+	}
+
+	defer shouldPanic("Invalid argument default", t)
+	newTestSchema(t, `
+		type Query {
+		  dog(name: String = 5): String
+		}
+	`, "Query", "").Finalize()
+}
+
+func TestDogResults(t *testing.T) {
+	sch := newTestSchema(t, dogSchema, "Query", "")
+	sch.AddResolveFunc("Dog", resolveDog)
+	sch.Finalize()
+
+	for query, expect := range dogResults {
+		res, err := executeQuery(t, sch, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := res.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(actual) != expect {
+			t.Errorf("Expected '%s', got '%s'", expect, actual)
+		}
+	}
+}
+
+func TestIntrospectionTypes(t *testing.T) {
+	sch := newTestSchema(t, dogSchema, "Query", "")
+	sch.AddResolveFunc("Dog", resolveDog)
+	sch.Finalize()
+
+	res, err := executeQuery(t, sch, `{ __schema { types { name } directives { name } } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := res.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data struct {
+		Schema struct {
+			Types      []struct{ Name string }
+			Directives []struct{ Name string }
+		} `json:"__schema"`
+	}
+	if err := json.Unmarshal(actual, &data); err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0)
+	for _, typ := range data.Schema.Types {
+		names = append(names, typ.Name)
+	}
+	if !strings.Contains(strings.Join(names, " "), "Boolean Dog Float ID Int Query String __Directive") {
+		t.Errorf("Unexpected types %v", names)
+	}
+
+	if len(data.Schema.Directives) != 2 || data.Schema.Directives[0].Name != "skip" {
+		t.Errorf("Unexpected directives %v", data.Schema.Directives)
+	}
+}
@@ -0,0 +1,99 @@
+package schema
+
+import (
+	"log"
+
+	"dylanmackenzie.com/graphql/ast"
+)
+
+// IsList reports whether the node resolves a list rather than a single
+// object.
+func (r *ResponseNode) IsList() bool {
+	return r.listType != nil
+}
+
+// Len returns the number of items in a list.
+func (r *ResponseNode) Len() int {
+	r.panicIfNotList()
+	return len(r.items)
+}
+
+// Item returns the i'th item of a list.
+func (r *ResponseNode) Item(i int) *ResponseNode {
+	r.panicIfNotList()
+	return r.items[i]
+}
+
+// Append adds an item to the end of a list and returns it. Items of
+// nullable types may be set to null with Null.
+//
+// The resolver of a list field is called once with the node for the
+// entire list. Rather than setting fields on that node, it appends a
+// node for each item and resolves the item as it would a single object.
+// Once the resolver returns, the sub-fields of every non-null item are
+// resolved. The items of a nested list such as [[User]] are themselves
+// lists, which are filled in the same way.
+func (r *ResponseNode) Append() *ResponseNode {
+	r.panicIfNotList()
+
+	item := &ResponseNode{
+		name:       r.name,
+		key:        r.key,
+		field:      r.field,
+		ctx:        r.ctx,
+		loaders:    r.loaders,
+		schema:     r.schema,
+		resultType: r.resultType,
+		isNullable: r.listType.OfType.Nullable(),
+		Fields:     make([]string, 0),
+		Args:       r.Args,
+		selected:   r.selected,
+		resultMap:  make(map[string]interface{}),
+		children:   make([]*ResponseNode, 0),
+		parent:     r,
+
+		// The items are resolved as part of the list, so waiting for
+		// the list waits for the items.
+		wg: r.wg,
+	}
+	item.listType, _ = r.listType.OfType.(*ast.ListType)
+
+	r.items = append(r.items, item)
+	return item
+}
+
+// SetLength appends or removes items until a list has n items.
+func (r *ResponseNode) SetLength(n int) {
+	r.panicIfNotList()
+
+	if n < len(r.items) {
+		r.items = r.items[:n]
+	}
+
+	for len(r.items) < n {
+		r.Append()
+	}
+}
+
+func (r *ResponseNode) panicIfNotList() {
+	if r.listType == nil {
+		log.Panicf("Field '%s' of type '%s' is not a list", r.name, r.resultType.TypeName())
+	}
+}
+
+// expandItems schedules the sub-fields of a node, or of every non-null
+// item if the node is a list.
+func expandItems(ss ast.SelectionSet, node *ResponseNode, ctx *executionContext) {
+	if node.null {
+		return
+	}
+
+	if node.listType == nil {
+		expandFields(ss, node, ctx)
+		return
+	}
+
+	for _, item := range node.items {
+		expandItems(ss, item, ctx)
+	}
+}
@@ -0,0 +1,105 @@
+package schema
+
+import "testing"
+
+var listSchema = `
+type Human {
+  name: String
+  friends: [Human]
+  squad: [[Human!]]
+  ranks: [[Int]!]
+}
+
+type Query {
+  hero: Human
+}
+`
+
+var humans = map[int]struct {
+	name    string
+	friends []int
+}{
+	1: {"Luke", []int{2, 3}},
+	2: {"Han", []int{1}},
+	3: {"Leia", nil},
+}
+
+var listResults = map[string]string{
+	`{ hero { name friends { name friends { name } } } }`: `{"hero":{"name":"Luke",` +
+		`"friends":[{"name":"Han","friends":[{"name":"Luke"}]},{"name":"Leia","friends":[]}]}}`,
+	`{ hero { squad { name } } }`: `{"hero":{"squad":[[{"name":"Luke"}],null]}}`,
+	`{ hero { ranks } }`:          `{"hero":{"ranks":[[1,2],[1]]}}`,
+}
+
+func setHuman(r *ResponseNode, id int) {
+	r.Set("id", id)
+	r.Set("name", humans[id].name)
+	r.Set("ranks", [][]int{{1, 2}, {id}})
+}
+
+func resolveHuman(r *ResponseNode) {
+	switch r.name {
+	case "hero":
+		setHuman(r, 1)
+
+	case "friends":
+		id, _ := r.Parent().GetAsInt("id")
+		for _, friend := range humans[id].friends {
+			setHuman(r.Append(), friend)
+		}
+
+	case "squad":
+		r.SetLength(2)
+		setHuman(r.Item(0).Append(), 1)
+		r.Item(1).Null(true)
+	}
+}
+
+func TestListResults(t *testing.T) {
+	sch := newTestSchema(t, listSchema, "Query", "")
+	sch.AddResolveFunc("Human", resolveHuman)
+	sch.Finalize()
+
+	for query, expect := range listResults {
+		res, err := executeQuery(t, sch, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := res.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(actual) != expect {
+			t.Errorf("Expected '%s', got '%s'", expect, actual)
+		}
+	}
+}
+
+func TestListLeafValue(t *testing.T) {
+	sch := newTestSchema(t, listSchema, "Query", "")
+	sch.AddResolveFunc("Human", resolveHuman)
+	sch.Finalize()
+
+	res, err := executeQuery(t, sch, `{ hero { ranks } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hero := res.children[0]
+	for _, v := range []interface{}{5, []int{1, 2}, [][]int{nil}} {
+		hero.Set("ranks", v)
+		if _, err := res.MarshalJSON(); err == nil {
+			t.Errorf("Expected '%v' not to be accepted for '[[Int]!]'", v)
+		}
+	}
+
+	hero.Set("ranks", [][]int(nil))
+	if actual, err := res.MarshalJSON(); err != nil || string(actual) != `{"hero":{"ranks":null}}` {
+		t.Errorf("Expected null list, got '%s' (%v)", actual, err)
+	}
+
+	defer shouldPanic("Append on object", t)
+	hero.Append()
+}
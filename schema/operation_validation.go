@@ -0,0 +1,117 @@
+package schema
+
+import "dylanmackenzie.com/graphql/ast"
+
+// validateOperation checks the active operation against the schema
+// before any of its fields is resolved, returning every error found.
+// The fields are collected, merged and given their arguments just as
+// they are once executed, so that an operation which passes can only
+// be failed by its resolvers.
+func (ctx *executionContext) validateOperation() errorList {
+	lazy := ctx.lazyPanic
+	ctx.lazyPanic = true
+	defer func() { ctx.lazyPanic = lazy }()
+
+	ctx.validateSelectionSet(ctx.Operation.SelectionSet, ctx.Root, true)
+	return ctx.Errors
+}
+
+// validateSelectionSet checks the fields selected on a value of type
+// def and their sub-fields. root is whether ss is the selection set of
+// the operation itself.
+func (ctx *executionContext) validateSelectionSet(ss ast.SelectionSet, def ast.AbstractTypeDefinition, root bool) {
+	groups := newFieldGroups()
+	groups.collect(ss, def, ctx)
+
+	// The arguments given to each leaf field, by name
+	leafArgs := make(map[string]ast.Arguments)
+
+	for _, key := range groups.keys {
+		sel := mergeFields(groups.fields[key], ctx)
+
+		name := sel.Name
+		field, ok := ctx.field(def, root, name)
+		if !ok {
+			ctx.addErrorf("Type has no field named '%s'", name)
+			continue
+		}
+
+		coerceArguments(field.Arguments, sel.Arguments, ctx)
+
+		if !ast.IsAbstractType(field.Definition) {
+			if len(sel.SelectionSet) != 0 {
+				ctx.addErrorf("Scalar type has sub-fields in query")
+			}
+
+			// Leaves are read from the values which the parent's
+			// resolver set by name, so aliases of a leaf cannot be
+			// given different arguments.
+			if args, ok := leafArgs[name]; ok && !sameArguments(args, sel.Arguments) {
+				ctx.addErrorf("Fields named '%s' conflict because they have different arguments", name)
+			}
+			leafArgs[name] = sel.Arguments
+			continue
+		}
+
+		if len(sel.SelectionSet) == 0 {
+			ctx.addErrorf("Abstract type has no sub-fields in query")
+			continue
+		}
+
+		// The fields of unions depend on the type of each value
+		if child, ok := field.Definition.(ast.AbstractTypeDefinition); ok {
+			ctx.validateSelectionSet(sel.SelectionSet, child, false)
+		}
+	}
+}
+
+// fragmentScope returns the type named by the type condition of a
+// fragment selected on a value of type scope, which is the scope of the
+// fragments the fragment selects in turn. Covers section 5.5.2.3 of
+// Validation.
+func (ctx *executionContext) fragmentScope(cond string, scope ast.AbstractTypeDefinition) ast.AbstractTypeDefinition {
+	switch t := ctx.Schema.types[cond].(type) {
+	case ast.AbstractTypeDefinition:
+		if fragmentApplies(t, scope) {
+			return t
+		}
+		ctx.addErrorf("Fragment on type '%s' can never apply to type '%s'", cond, scope.TypeName())
+	case *ast.UnionDefinition:
+		// The members of unions are not checked
+	case nil:
+		ctx.addErrorf("Fragment is on type '%s', which is not found in schema", cond)
+	default:
+		ctx.addErrorf("Fragment is on type '%s', which is not an object, interface or union", cond)
+	}
+
+	return scope
+}
+
+// fragmentApplies reports whether a value of type def may also be of
+// type cond, so that a fragment on cond may select its fields.
+func fragmentApplies(cond, def ast.AbstractTypeDefinition) bool {
+	if cond.TypeName() == def.TypeName() {
+		return true
+	}
+
+	// An interface applies to the objects implementing it, and may
+	// overlap with any other interface
+	obj, ok := cond.(*ast.ObjectDefinition)
+	iface := def
+	if !ok {
+		if obj, ok = def.(*ast.ObjectDefinition); !ok {
+			return true
+		}
+		iface = cond
+	}
+
+	if _, ok := iface.(*ast.InterfaceDefinition); !ok {
+		return false
+	}
+	for _, name := range obj.Implements {
+		if name == iface.TypeName() {
+			return true
+		}
+	}
+	return false
+}
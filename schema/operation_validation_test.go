@@ -0,0 +1,44 @@
+package schema
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"dylanmackenzie.com/graphql/ast"
+)
+
+func TestValidateOperation(t *testing.T) {
+	sch := newTestSchema(t, bankSchema, "Query", "Mutation")
+	sch.AddResolveFunc("Account", func(r *ResponseNode) {
+		t.Errorf("Resolver of '%s' called for an invalid operation", r.name)
+	})
+	sch.Finalize()
+
+	// Each document is named after its operation
+	for _, query := range []string{
+		`mutation ArgumentType { deposit(amount: 5) { balance } withdraw(amount: "5") { balance } }`,
+		`mutation UnknownArgument { deposit(amount: 5, currency: "EUR") { balance } }`,
+		`mutation LeafAliases { deposit(amount: 5) { a: balance b: balance(in: "EUR") } }`,
+		`query NestedField { account { owner { age } } }`,
+		`query FieldConflict { account { name: balance name: owner { name } } }`,
+		`query DirectiveType { account { balance @skip(if: "true") } }`,
+		`query FragmentCycle { account { ...A } } fragment A on Account { owner { name } ...A }`,
+		`query FragmentOnType { account { ...O } } fragment O on Owner { name }`,
+		`query UnknownCondition { account { ... on Person { balance } } }`,
+	} {
+		doc, err := ast.FromReader(strings.NewReader(query))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		name := doc.Definitions[0].(*ast.OperationDefinition).Name
+		ctx, err := Execute(context.Background(), sch, &doc, name)
+		if err == nil {
+			t.Errorf("%s: Expected an error", name)
+		}
+		if ctx.Response != nil {
+			t.Errorf("%s: Expected no response", name)
+		}
+	}
+}
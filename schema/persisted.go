@@ -0,0 +1,159 @@
+package schema
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// A PersistedQueryStore holds the documents of automatic persisted
+// queries by the SHA-256 hash of their text, in lowercase hex. Its
+// methods may be called concurrently.
+type PersistedQueryStore interface {
+	// Get returns the document stored with the given hash.
+	Get(hash string) (query string, ok bool)
+
+	// Put stores a document with its hash.
+	Put(hash, query string)
+}
+
+// A persistedQueryError is an error answering a persisted query. Clients
+// identify it by its message, and it is written with a code in the
+// extensions of the response error.
+type persistedQueryError struct {
+	message string
+	code    string
+}
+
+var (
+	errPersistedQueryNotFound     = &persistedQueryError{"PersistedQueryNotFound", "PERSISTED_QUERY_NOT_FOUND"}
+	errPersistedQueryNotSupported = &persistedQueryError{"PersistedQueryNotSupported", "PERSISTED_QUERY_NOT_SUPPORTED"}
+)
+
+func (e *persistedQueryError) Error() string { return e.message }
+
+func (e *persistedQueryError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+// persistedQuery resolves the document of a request which gives the
+// SHA-256 hash of its document in the persistedQuery extension:
+//
+//	{"extensions": {"persistedQuery": {"version": 1, "sha256Hash": "..."}}}
+//
+// If no document is stored with that hash, the request fails with a
+// PersistedQueryNotFound error, and the client retries with both the
+// document and its hash. The hash with which that document is to be
+// registered once it has been parsed is returned. Since later requests
+// only give the hash, they are small enough to be sent by GET and
+// cached by a CDN.
+//
+// status is the status of a response to a request which fails before
+// execution, which is returned along with an error that prevents the
+// request from being executed.
+func (h *handler) persistedQuery(info *RequestInfo, status int) (register string, errStatus int, err error) {
+	ext, ok := info.Extensions["persistedQuery"]
+	if !ok {
+		return "", 0, nil
+	}
+
+	if h.opts.PersistedQueries == nil {
+		return "", status, errPersistedQueryNotSupported
+	}
+
+	pq, _ := ext.(map[string]interface{})
+	if version, _ := pq["version"].(json.Number); version != "1" {
+		return "", http.StatusBadRequest, fmt.Errorf("Unsupported persisted query version %v", pq["version"])
+	}
+
+	hash, _ := pq["sha256Hash"].(string)
+	if hash == "" {
+		return "", http.StatusBadRequest, errors.New("Persisted query has no sha256Hash")
+	}
+
+	// Hex digits may be given in either case, but documents are stored
+	// by their hash in lowercase
+	hash = strings.ToLower(hash)
+
+	if info.Query == "" {
+		query, ok := h.opts.PersistedQueries.Get(hash)
+		if !ok {
+			return "", status, errPersistedQueryNotFound
+		}
+		info.Query = query
+		return "", 0, nil
+	}
+
+	sum := sha256.Sum256([]byte(info.Query))
+	if hex.EncodeToString(sum[:]) != hash {
+		return "", http.StatusBadRequest, errors.New("Persisted query sha256Hash does not match the query")
+	}
+
+	return hash, 0, nil
+}
+
+// NewPersistedQueryCache returns a PersistedQueryStore which keeps up to
+// size documents in memory, discarding the least recently used once it
+// is full.
+func NewPersistedQueryCache(size int) PersistedQueryStore {
+	if size <= 0 {
+		log.Panicf("Persisted query cache must hold at least one query, not %d", size)
+	}
+
+	return &lruStore{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// lruStore is a PersistedQueryStore which discards the least recently
+// used document once it holds size documents.
+type lruStore struct {
+	size int
+
+	mu      sync.Mutex
+	order   *list.List               // The entries, from most to least recently used.
+	entries map[string]*list.Element // The elements of order, by hash.
+}
+
+type lruEntry struct {
+	hash, query string
+}
+
+func (s *lruStore) Get(hash string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[hash]
+	if !ok {
+		return "", false
+	}
+
+	s.order.MoveToFront(e)
+	return e.Value.(*lruEntry).query, true
+}
+
+func (s *lruStore) Put(hash, query string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[hash]; ok {
+		s.order.MoveToFront(e)
+		return
+	}
+
+	s.entries[hash] = s.order.PushFront(&lruEntry{hash, query})
+	if s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*lruEntry).hash)
+	}
+}
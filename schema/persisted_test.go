@@ -0,0 +1,96 @@
+package schema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPersistedQueries(t *testing.T) {
+	sch := newTestSchema(t, bankSchema, "Query", "")
+	sch.AddResolveFunc("Account", func(r *ResponseNode) {
+		r.Set("balance", 10)
+	})
+	h := sch.HandlerWithOptions(HandlerOptions{PersistedQueries: NewPersistedQueryCache(10)})
+
+	query := "{ account { balance } }"
+	sum := sha256.Sum256([]byte(query))
+	hash := hex.EncodeToString(sum[:])
+
+	get := func(query, hash string) *httptest.ResponseRecorder {
+		q := url.Values{"extensions": {`{"persistedQuery": {"version": 1, "sha256Hash": "` + hash + `"}}`}}
+		if query != "" {
+			q.Set("query", query)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/graphql?"+q.Encode(), nil))
+		return w
+	}
+
+	var res struct {
+		Errors []struct {
+			Message    string
+			Extensions struct{ Code string }
+		}
+	}
+	w := get("", hash)
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Errors) != 1 || res.Errors[0].Message != "PersistedQueryNotFound" || res.Errors[0].Extensions.Code != "PERSISTED_QUERY_NOT_FOUND" {
+		t.Errorf("Expected PersistedQueryNotFound, got '%s'", w.Body)
+	}
+
+	if w := get(query, hash[1:]+"0"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a mismatched hash to be rejected, got status %d", w.Code)
+	}
+
+	// The case of the hash does not matter
+	expect := `{"data":{"account":{"balance":10}}}`
+	for _, q := range []struct{ query, hash string }{
+		{query, strings.ToUpper(hash)},
+		{"", hash},
+		{"", strings.ToUpper(hash)},
+	} {
+		if w := get(q.query, q.hash); w.Body.String() != expect {
+			t.Errorf("%s: Expected '%s', got '%s'", q.hash, expect, w.Body)
+		}
+	}
+
+	// Invalid documents are not registered
+	bad := "{ account { "
+	sum = sha256.Sum256([]byte(bad))
+	get(bad, hex.EncodeToString(sum[:]))
+	if _, ok := h.(*handler).opts.PersistedQueries.Get(hex.EncodeToString(sum[:])); ok {
+		t.Error("Invalid document was registered")
+	}
+
+	w = httptest.NewRecorder()
+	sch.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/graphql?extensions="+url.QueryEscape(`{"persistedQuery": {"version": 1, "sha256Hash": "`+hash+`"}}`), nil))
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Errors) != 1 || res.Errors[0].Message != "PersistedQueryNotSupported" {
+		t.Errorf("Expected PersistedQueryNotSupported, got '%s'", w.Body)
+	}
+}
+
+func TestPersistedQueryCache(t *testing.T) {
+	s := NewPersistedQueryCache(2)
+	s.Put("a", "{ a }")
+	s.Put("b", "{ b }")
+	s.Get("a")
+	s.Put("c", "{ c }")
+
+	for hash, expect := range map[string]string{"a": "{ a }", "b": "", "c": "{ c }"} {
+		if query, _ := s.Get(hash); query != expect {
+			t.Errorf("%s: Expected '%s', got '%s'", hash, expect, query)
+		}
+	}
+}
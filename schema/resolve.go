@@ -1,5 +1,7 @@
 package schema
 
+import "fmt"
+
 // ResolveFunc is a callback which resolves the selection set of a
 // GraphQL Object.
 //
@@ -17,3 +19,26 @@ func (res ResolveFunc) ResolveGraphQL(r *ResponseNode) {
 type Resolver interface {
 	ResolveGraphQL(r *ResponseNode)
 }
+
+// DefaultResolver resolves the types for which no resolver was added.
+// It sets the node from the Go value which the parent's resolver stored
+// under the name of the field, as if by SetValue, so that a tree of Go
+// values set at its root is resolved without any further resolvers. It
+// is an error for the parent to have set no value for the field.
+var DefaultResolver Resolver = ResolveFunc(resolveDefault)
+
+func resolveDefault(r *ResponseNode) {
+	if r.parent == nil {
+		panic(fmt.Errorf("No resolver added for type '%s'", r.resultType.TypeName()))
+	}
+
+	value, ok := r.parent.Get(r.name)
+	if !ok {
+		panic(fmt.Errorf("No resolver added for type '%s', and no value set for field '%s'",
+			r.resultType.TypeName(), r.name))
+	}
+
+	if err := r.SetValue(value); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,154 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"dylanmackenzie.com/graphql/ast"
+)
+
+var defaultSchema = `
+type Planet {
+  name: String!
+  population: Int
+}
+
+type Person {
+  name: String!
+  homeworld: Planet
+  friends: [Person!]
+  greeting: String
+  secret: String
+  age: Int
+}
+
+type Query {
+  people: [Person]
+}
+`
+
+type planet struct {
+	Name       string `json:"name"`
+	Population int64  `json:"population,omitempty"`
+}
+
+type person struct {
+	FullName  string `graphql:"name"`
+	Homeworld *planet
+	Friends   []*person
+}
+
+func (p *person) Greeting(c context.Context) string {
+	return "Hello, " + p.FullName
+}
+
+func (p *person) Secret() (string, error) {
+	return "", errors.New("Secrets are not shared")
+}
+
+func TestDefaultResolver(t *testing.T) {
+	tatooine := &planet{Name: "Tatooine", Population: 200000}
+	luke := &person{FullName: "Luke", Homeworld: tatooine}
+	leia := &person{FullName: "Leia", Friends: []*person{luke}}
+	luke.Friends = []*person{leia}
+
+	sch := newTestSchema(t, defaultSchema, "Query", "")
+	sch.AddResolveFunc("Query", func(r *ResponseNode) {
+		han := map[string]interface{}{"name": "Han", "greeting": nil, "homeworld": nil, "friends": nil}
+		people := []interface{}{luke, nil, han}
+		if err := r.SetField("people", people); err != nil {
+			t.Error(err)
+		}
+	})
+	sch.Finalize()
+
+	doc, err := ast.FromReader(strings.NewReader(`{
+		people {
+			__typename
+			name
+			greeting
+			homeworld { name population }
+			friends { name friends { name } }
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := Execute(context.Background(), sch, &doc, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := ctx.Response.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{"people":[` +
+		`{"__typename":"Person","name":"Luke","greeting":"Hello, Luke",` +
+		`"homeworld":{"name":"Tatooine","population":200000},` +
+		`"friends":[{"name":"Leia","friends":[{"name":"Luke"}]}]},` +
+		`null,` +
+		`{"__typename":"Person","name":"Han","greeting":null,"homeworld":null,"friends":null}]}`
+	if string(actual) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, actual)
+	}
+}
+
+func TestDefaultResolverErrors(t *testing.T) {
+	sch := newTestSchema(t, defaultSchema, "Query", "")
+	sch.AddResolveFunc("Query", func(r *ResponseNode) {
+		r.SetField("people", []*person{{FullName: "Luke"}})
+	})
+	sch.Finalize()
+
+	for query, fails := range map[string]bool{
+		`{ people { secret } }`:             true,  // The method returns an error
+		`{ people { homeworld { name } } }`: false, // A nil pointer is null
+		`{ people { age } }`:                true,  // There is no Go field or method
+	} {
+		doc, err := ast.FromReader(strings.NewReader(query))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Execute(context.Background(), sch, &doc, ""); (err != nil) != fails {
+			t.Errorf("%s: Expected failure to be %t, got %v", query, fails, err)
+		}
+	}
+}
+
+type named struct {
+	Name string `json:"name"`
+}
+
+func TestDefaultResolverEmbedded(t *testing.T) {
+	sch := newTestSchema(t, defaultSchema, "Query", "")
+	sch.AddResolveFunc("Query", func(r *ResponseNode) {
+		r.SetField("people", []map[string]interface{}{
+			{"homeworld": struct {
+				named
+				Population int
+			}{named{"Endor"}, 30000}},
+			{"homeworld": &struct {
+				*named
+				Population int
+			}{&named{"Hoth"}, 0}},
+		})
+	})
+	sch.Finalize()
+
+	res, err := executeQuery(t, sch, `{ people { homeworld { name population } } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, _ := res.MarshalJSON()
+	expect := `{"people":[{"homeworld":{"name":"Endor","population":30000}},{"homeworld":{"name":"Hoth","population":0}}]}`
+	if string(actual) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, actual)
+	}
+}
@@ -2,16 +2,27 @@ package schema
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"reflect"
 	"sync"
 
 	"dylanmackenzie.com/graphql/ast"
+	"dylanmackenzie.com/graphql/dataloader"
 )
 
 type ResponseNode struct {
-	//
-	name string
+	name  string         // The name of the field resolved by this node.
+	key   string         // The key of the node in the response.
+	field *ast.TypeField // The declaration of the field, or nil for the root.
+
+	// The context, loaders and schema of the request which this node
+	// is a part of.
+	ctx     context.Context
+	loaders *dataloader.Group
+	schema  *Schema
 
 	// The type expected as a result of this response node
 	resultType ast.AbstractTypeDefinition
@@ -22,21 +33,38 @@ type ResponseNode struct {
 	// map corresponding to their name.
 	resultMap
 
-	Fields []string  // List of fields that must be resolved.
-	Args   resultMap // The arguments for the current node.
-	null   bool      // Whether or not the response is null.
+	Fields    []string        // List of fields that must be resolved.
+	completed map[string]bool // The leaf values already converted to their types.
+	Args      resultMap       // The arguments for the current node.
+	null      bool            // Whether or not the response is null.
+
+	// The fields selected on the node, in the order in which they
+	// appear in the response, and the names of the fields selected,
+	// which are known before the node's resolver is called.
+	selections []selection
+	selected   map[string]bool
 
 	parent   *ResponseNode   // The ResponseNode that initiated this one.
 	children []*ResponseNode // All Response nodes initiated by this one.
 
-	// If a ResponseNode is cloned to create a list of responses, this
-	// contains a pointer to all nodes that will comprise the list.
-	siblings []*ResponseNode
+	// If the node resolves a list, the type of the list and the nodes
+	// of its items, which are themselves lists if the list is nested.
+	listType *ast.ListType
+	items    []*ResponseNode
 
 	resolved bool            // Whether or not this ResponseNode has been resolved.
 	wg       *sync.WaitGroup // The WaitGroup waiting for this ResponseNode to resolve.
 }
 
+// A selection is a field selected on an object, along with the key
+// under which it appears in the response. For fields which are not
+// leaves, node is the ResponseNode which resolves the field.
+type selection struct {
+	key   string
+	field *ast.TypeField
+	node  *ResponseNode
+}
+
 // Constructor for a response node. Only for initializing the
 // map and slice types which should never be nil.
 func NewResponseNode(parent *ResponseNode, field *ast.TypeField) *ResponseNode {
@@ -47,6 +75,7 @@ func NewResponseNode(parent *ResponseNode, field *ast.TypeField) *ResponseNode {
 		children:  make([]*ResponseNode, 0),
 		parent:    parent,
 		name:      "__root",
+		key:       "__root",
 		wg:        new(sync.WaitGroup),
 	}
 
@@ -60,18 +89,81 @@ func NewResponseNode(parent *ResponseNode, field *ast.TypeField) *ResponseNode {
 			panic("NewResponseNode called with field which is not abstract")
 		}
 
+		node.field = field
 		node.resultType = def
 		node.isNullable = field.Type.Nullable()
 		node.name = field.Name
+		node.key = field.Name
+		node.listType, _ = field.Type.(*ast.ListType)
 	}
 
 	if parent != nil {
+		node.ctx = parent.ctx
+		node.loaders = parent.loaders
+		node.schema = parent.schema
 		parent.children = append(parent.children, node)
 	}
 
 	return node
 }
 
+// Set stores the value of a field of the object resolved by the node,
+// or any other value for the resolvers of its sub-fields to retrieve.
+// Unlike SetField, the value is only checked against the field's type
+// once the resolver returns.
+func (r *ResponseNode) Set(key string, value interface{}) {
+	r.resultMap.Set(key, value)
+	delete(r.completed, key)
+}
+
+// complete stores the value of a leaf field which has already been
+// converted to the field's type.
+func (r *ResponseNode) complete(key string, value interface{}) {
+	if r.completed == nil {
+		r.completed = make(map[string]bool)
+	}
+
+	r.resultMap.Set(key, value)
+	r.completed[key] = true
+}
+
+// Name returns the name of the field resolved by the node.
+func (r *ResponseNode) Name() string {
+	return r.name
+}
+
+// Key returns the key of the node in the response, which is the alias
+// of its field if it has one, and the name of the field otherwise.
+// Fields selected more than once with different aliases are resolved
+// by separate nodes.
+func (r *ResponseNode) Key() string {
+	return r.key
+}
+
+// Parent returns the node whose resolver caused this node to be
+// resolved, or nil for the root of the response. The parent of a list
+// item is the list.
+func (r *ResponseNode) Parent() *ResponseNode {
+	return r.parent
+}
+
+// Context returns the context of the request being resolved. Resolvers
+// should stop their work once it is done.
+func (r *ResponseNode) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+
+	return r.ctx
+}
+
+// Loader returns the request's Loader for the named type, as
+// registered with Schema.AddLoader. Values loaded by the resolvers of
+// sibling fields are batched into a single call of the batch function.
+func (r *ResponseNode) Loader(name string) *dataloader.Loader {
+	return r.loaders.Loader(name)
+}
+
 func (r *ResponseNode) panicIfResolved() {
 	if r.resolved {
 		log.Panicf("Response for field '%s' has already been resolved", r.resultType.TypeName())
@@ -93,6 +185,29 @@ func (r *ResponseNode) resolve() {
 	r.resolved = true
 }
 
+// isNull reports whether the node will be serialized as null, either
+// because it was set to null or because one of its non-nullable
+// children or items was.
+func (r *ResponseNode) isNull() bool {
+	if r.null {
+		return true
+	}
+
+	for _, child := range r.children {
+		if !child.isNullable && child.isNull() {
+			return true
+		}
+	}
+
+	for _, item := range r.items {
+		if !item.isNullable && item.isNull() {
+			return true
+		}
+	}
+
+	return false
+}
+
 // ResponseNode implements json.Marshaler
 func (r *ResponseNode) MarshalJSON() ([]byte, error) {
 	buf := new(bytes.Buffer)
@@ -101,13 +216,10 @@ func (r *ResponseNode) MarshalJSON() ([]byte, error) {
 }
 
 func (r *ResponseNode) marshalJSON(buf *bytes.Buffer) error {
-	// Handle null ResponseNode
-	if r.null == true {
-		if !r.isNullable {
-			panic("Response node for non-nullable type has been set to null")
-		}
-
-		log.Println("null")
+	// Handle null ResponseNode. A null in a non-nullable field is
+	// propagated to its parent by isNull, so it is never written here
+	// unless the node is the root.
+	if r.isNull() {
 		_, err := buf.Write([]byte("null"))
 		if err != nil {
 			return err
@@ -117,15 +229,16 @@ func (r *ResponseNode) marshalJSON(buf *bytes.Buffer) error {
 
 	}
 
+	if r.listType != nil {
+		return marshalList(r, buf)
+	}
+
 	if err := buf.WriteByte(byte('{')); err != nil {
 		return err
 	}
 
-	for i, fieldName := range r.Fields {
-		field, ok := r.resultType.Field(fieldName)
-		if !ok {
-			panic("MarshalJSON called on node with invalid field")
-		}
+	for i, sel := range r.selections {
+		field, fieldName := sel.field, sel.field.Name
 
 		if i != 0 {
 			if err := buf.WriteByte(byte(',')); err != nil {
@@ -137,7 +250,7 @@ func (r *ResponseNode) marshalJSON(buf *bytes.Buffer) error {
 			return err
 		}
 
-		if _, err := buf.WriteString(fieldName); err != nil {
+		if _, err := buf.WriteString(sel.key); err != nil {
 			return err
 		}
 
@@ -149,10 +262,23 @@ func (r *ResponseNode) marshalJSON(buf *bytes.Buffer) error {
 		// write it to the buffer.
 		if !ast.IsAbstractType(field.Definition) {
 			result, ok := r.resultMap.Get(fieldName)
+			if fieldName == "__typename" {
+				result, ok = r.resultType.TypeName(), true
+			}
 			if !ok {
 				panic("No field set")
 			}
 
+			// Values set after the node was completed have not been
+			// converted yet.
+			if !r.completed[fieldName] {
+				var err error
+				result, err = r.schema.coerceResult(reflect.ValueOf(result), field.Type, field.Definition)
+				if err != nil {
+					return fmt.Errorf("Field '%s': %s", fieldName, err)
+				}
+			}
+
 			json, err := json.Marshal(result)
 			if err != nil {
 				return err
@@ -165,23 +291,10 @@ func (r *ResponseNode) marshalJSON(buf *bytes.Buffer) error {
 			continue
 		}
 
-		// Otherwise, the field is abstract, so we look in the list of
-		// children for the ResponseNode which resolves it.
-		var node *ResponseNode
-		for _, child := range r.children {
-			if child.name == field.Name {
-				node = child
-			}
-		}
-
-		if _, ok := field.Type.(*ast.ListType); ok {
-			if err := marshalList(node, buf); err != nil {
-				return err
-			}
-		} else {
-			if err := node.marshalJSON(buf); err != nil {
-				return err
-			}
+		// Otherwise, the field is abstract, so we write the
+		// ResponseNode which resolves it.
+		if err := sel.node.marshalJSON(buf); err != nil {
+			return err
 		}
 	}
 
@@ -195,14 +308,14 @@ func marshalList(r *ResponseNode, buf *bytes.Buffer) error {
 	if err := buf.WriteByte(byte('[')); err != nil {
 		return err
 	}
-	if err := r.marshalJSON(buf); err != nil {
-		return err
-	}
-	for _, sibling := range r.siblings {
-		if err := buf.WriteByte(byte(',')); err != nil {
-			return err
+
+	for i, item := range r.items {
+		if i != 0 {
+			if err := buf.WriteByte(byte(',')); err != nil {
+				return err
+			}
 		}
-		if err := sibling.marshalJSON(buf); err != nil {
+		if err := item.marshalJSON(buf); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,416 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"dylanmackenzie.com/graphql/ast"
+)
+
+// Selected reports whether the named field is selected on the object
+// resolved by r.
+func (r *ResponseNode) Selected(name string) bool {
+	return r.selected[name]
+}
+
+// SetField sets the value of the named field of the object resolved by
+// r, after converting it to the field's type. It returns an error if
+// the type has no such field or the value is not of its type. Values of
+// fields which are not selected are ignored. Unlike Set, the value is
+// checked as it is set, so that a mistake in a resolver is reported
+// where it is made rather than when the response is serialized.
+//
+// The values of fields which are not leaves are stored without being
+// checked, so that the resolvers of those fields may retrieve them from
+// their parent with Get.
+func (r *ResponseNode) SetField(name string, value interface{}) error {
+	if r.listType != nil {
+		return fmt.Errorf("Cannot set field '%s' on a list of type '%s'", name, r.listType.Name())
+	}
+
+	field, ok := r.resultType.Field(name)
+	if !ok {
+		return fmt.Errorf("Type '%s' has no field named '%s'", r.resultType.TypeName(), name)
+	}
+
+	if !r.selected[name] {
+		return nil
+	}
+
+	if ast.IsAbstractType(field.Definition) {
+		r.Set(name, value)
+		return nil
+	}
+
+	v, err := r.schema.coerceResult(reflect.ValueOf(value), field.Type, field.Definition)
+	if err != nil {
+		return fmt.Errorf("Field '%s': %s", name, err)
+	}
+
+	r.complete(name, v)
+	return nil
+}
+
+// SetObject sets every selected field of the object resolved by r from
+// a Go value, as if by SetField. v must be a struct, a map with string
+// keys, or a pointer to either. Each GraphQL field is taken from the
+// first of:
+//
+//	the struct field whose `graphql` tag gives its name
+//	the struct field whose `json` tag gives its name
+//	the struct field whose name matches it regardless of case
+//	the method whose name matches it regardless of case
+//	the value of the map under its name
+//
+// A method may take a context.Context, which is the request's context,
+// and may return an error as its second result. Fields with no value
+// in v are left unset.
+func (r *ResponseNode) SetObject(v interface{}) error {
+	val := reflect.ValueOf(v)
+	obj := reflect.Indirect(val)
+	switch {
+	case obj.Kind() == reflect.Struct:
+		// A pointer is kept so that its methods may be called.
+	case obj.Kind() == reflect.Map && obj.Type().Key().Kind() == reflect.String:
+		val = obj
+	default:
+		return fmt.Errorf("SetObject requires a struct or a map with string keys, got %T", v)
+	}
+
+	for name := range r.selected {
+		// Introspection fields are never set by resolvers
+		if strings.HasPrefix(name, "__") {
+			continue
+		}
+
+		value, ok, err := lookupGoField(val, name, r.Context())
+		if err != nil {
+			return fmt.Errorf("Field '%s': %s", name, err)
+		}
+		if !ok {
+			continue
+		}
+
+		if err := r.SetField(name, value.Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetValue sets the object or list resolved by r from a Go value. nil
+// sets the node to null, a slice or array sets the items of a list,
+// and any other value sets the fields of an object as if by SetObject.
+// Unlike SetObject, it is an error for a selected field to have no
+// value in v.
+func (r *ResponseNode) SetValue(v interface{}) error {
+	val := reflect.ValueOf(v)
+	if isNilValue(val) {
+		if !r.isNullable {
+			return fmt.Errorf("Null value for non-nullable field '%s'", r.name)
+		}
+
+		r.Null(true)
+		return nil
+	}
+
+	if r.listType == nil {
+		if err := r.SetObject(v); err != nil {
+			return err
+		}
+
+		for name := range r.selected {
+			if _, ok := r.Get(name); !ok && !strings.HasPrefix(name, "__") {
+				return fmt.Errorf("Field '%s' has no value in %T", name, v)
+			}
+		}
+		return nil
+	}
+
+	val = reflect.Indirect(val)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return fmt.Errorf("Value of type %T is not a list of type '%s'", v, r.listType.Name())
+	}
+
+	for i := 0; i < val.Len(); i++ {
+		if err := r.Append().SetValue(val.Index(i).Interface()); err != nil {
+			return fmt.Errorf("Item %d: %s", i, err)
+		}
+	}
+
+	return nil
+}
+
+var (
+	contextType   = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType     = reflect.TypeOf((*error)(nil)).Elem()
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// lookupGoField finds the value of the named GraphQL field in a struct,
+// a pointer to a struct, or a map. The fields of embedded structs are
+// found as if they were fields of the struct itself.
+func lookupGoField(v reflect.Value, name string, c context.Context) (reflect.Value, bool, error) {
+	if v.Kind() == reflect.Map {
+		item := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		return item, item.IsValid(), nil
+	}
+
+	obj := reflect.Indirect(v)
+	var byJSON, byName []int
+	for _, field := range reflect.VisibleFields(obj.Type()) {
+		if !field.IsExported() {
+			continue
+		}
+
+		switch field.Tag.Get("graphql") {
+		case name:
+			return embeddedField(obj, field.Index)
+		case "":
+		default:
+			continue
+		}
+
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag == name && byJSON == nil {
+			byJSON = field.Index
+		} else if tag == "" && byName == nil && strings.EqualFold(field.Name, name) {
+			byName = field.Index
+		}
+	}
+
+	switch {
+	case byJSON != nil:
+		return embeddedField(obj, byJSON)
+	case byName != nil:
+		return embeddedField(obj, byName)
+	}
+
+	for i := 0; i < v.NumMethod(); i++ {
+		if strings.EqualFold(v.Type().Method(i).Name, name) {
+			return callMethod(v.Method(i), c)
+		}
+	}
+
+	return reflect.Value{}, false, nil
+}
+
+// embeddedField returns the field of a struct at the given index, as
+// given by reflect.VisibleFields. A field promoted from a nil embedded
+// pointer is null.
+func embeddedField(obj reflect.Value, index []int) (reflect.Value, bool, error) {
+	field, err := obj.FieldByIndexErr(index)
+	if err != nil {
+		return reflect.Zero(interfaceType), true, nil
+	}
+
+	return field, true, nil
+}
+
+// callMethod calls a method which resolves a field. It reports false if
+// the method's signature is not one of
+//
+//	func() T
+//	func() (T, error)
+//	func(context.Context) T
+//	func(context.Context) (T, error)
+func callMethod(m reflect.Value, c context.Context) (reflect.Value, bool, error) {
+	t := m.Type()
+
+	var args []reflect.Value
+	switch {
+	case t.NumIn() == 0:
+	case t.NumIn() == 1 && t.In(0) == contextType:
+		args = []reflect.Value{reflect.ValueOf(c)}
+	default:
+		return reflect.Value{}, false, nil
+	}
+
+	switch {
+	case t.NumOut() == 1:
+	case t.NumOut() == 2 && t.Out(1) == errorType:
+	default:
+		return reflect.Value{}, false, nil
+	}
+
+	out := m.Call(args)
+	if len(out) == 2 && !out[1].IsNil() {
+		return reflect.Value{}, false, out[1].Interface().(error)
+	}
+
+	return out[0], true, nil
+}
+
+// coerceResult converts a Go value to the representation of a leaf type
+// in the response. It is called for every leaf value, including those
+// stored with Set, once the resolver which set them returns. A value
+// which cannot be converted is replaced by null and reported as an
+// error of its field.
+//
+//	Int            int, from any integer, or float without a fractional
+//	               part, which fits in 32 bits
+//	Float          float64, from any integer or finite float
+//	String         string, from any string, integer, float or bool
+//	ID             string, from any string or integer
+//	Boolean        bool
+//	Enum           the name of a value, from a Go value bound by AddEnum
+//	               or a string naming the value
+//	List           []interface{}, from any slice or array
+//
+// A nil pointer, slice, map or interface is null, and any other pointer
+// is replaced by the value it points to.
+func (sch *Schema) coerceResult(v reflect.Value, desc ast.TypeDescriptor, def ast.TypeDefinition) (interface{}, error) {
+	orig := v
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			break
+		}
+		v = v.Elem()
+	}
+
+	if isNilValue(v) {
+		if !desc.Nullable() {
+			return nil, fmt.Errorf("Null value for non-nullable type '%s'", desc.Name())
+		}
+		return nil, nil
+	}
+
+	if list, ok := desc.(*ast.ListType); ok {
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, fmt.Errorf("Value of type %s is not a list of type '%s'", v.Type(), list.Name())
+		}
+
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			item, err := sch.coerceResult(v.Index(i), list.OfType, def)
+			if err != nil {
+				return nil, fmt.Errorf("Item %d: %s", i, err)
+			}
+			out[i] = item
+		}
+		return out, nil
+	}
+
+	switch t := def.(type) {
+	case *ast.EnumDefinition:
+		return sch.serializeEnum(v, t)
+
+	case *ast.ScalarDefinition:
+		// Custom scalars are given the value as it was set, which
+		// may be a pointer.
+		if s, ok := sch.scalar(t.Name); ok {
+			return s.serialize(orig.Interface(), t.Name)
+		}
+		return coerceScalarResult(v, t)
+	}
+
+	return nil, fmt.Errorf("Value %v of type %s is not of type '%s'", v.Interface(), v.Type(), def.TypeName())
+}
+
+// coerceScalarResult converts a Go value to a built-in scalar, as given
+// in section 3.1.1 of Type System.
+func coerceScalarResult(v reflect.Value, def *ast.ScalarDefinition) (interface{}, error) {
+	switch def.Kind {
+	case reflect.Int:
+		if i, ok := intValue(v); ok {
+			if i < math.MinInt32 || i > math.MaxInt32 {
+				return nil, fmt.Errorf("Value %d does not fit in a 32-bit '%s'", i, def.Name)
+			}
+			return int(i), nil
+		}
+
+		// Floats are accepted if they have no fractional part, and are
+		// only converted once known to fit
+		if f, ok := floatValue(v); ok && f == math.Trunc(f) {
+			if f < math.MinInt32 || f > math.MaxInt32 {
+				return nil, fmt.Errorf("Value %g does not fit in a 32-bit '%s'", f, def.Name)
+			}
+			return int(f), nil
+		}
+
+	case reflect.Float64:
+		if f, ok := floatValue(v); ok {
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				return nil, fmt.Errorf("Value %g is not a finite '%s'", f, def.Name)
+			}
+			return f, nil
+		}
+		if i, ok := intValue(v); ok {
+			return float64(i), nil
+		}
+
+	case reflect.String:
+		if v.Kind() == reflect.String {
+			return v.String(), nil
+		}
+		if i, ok := intValue(v); ok {
+			return strconv.FormatInt(i, 10), nil
+		}
+
+		// Only IDs are restricted to strings and integers
+		if def.Name == "ID" {
+			break
+		}
+		if v.Kind() == reflect.Bool {
+			return strconv.FormatBool(v.Bool()), nil
+		}
+		if f, ok := floatValue(v); ok {
+			return strconv.FormatFloat(f, 'g', -1, 64), nil
+		}
+
+	case reflect.Bool:
+		if v.Kind() == reflect.Bool {
+			return v.Bool(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("Value %v of type %s is not of type '%s'", v.Interface(), v.Type(), def.Name)
+}
+
+// floatValue returns the value of any float kind. float32 values are
+// converted to the float64 with the same shortest representation, so
+// that 0.1 is not serialized as 0.10000000149011612.
+func floatValue(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Float32:
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(v.Float(), 'g', -1, 32), 64)
+		return f, true
+	case reflect.Float64:
+		return v.Float(), true
+	}
+
+	return 0, false
+}
+
+// intValue returns the value of any integer kind which fits in an
+// int64.
+func intValue(v reflect.Value) (int64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v.Uint()), true
+	}
+
+	return 0, false
+}
+
+// isNilValue reports whether v is the zero Value or a nil pointer,
+// slice, map or interface.
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return v.IsNil()
+	}
+
+	return false
+}
@@ -1,7 +1,9 @@
 package schema
 
 // A simple wrapper around a map that allows struct embedding and a
-// convenient syntax for converting a value to a given type.
+// convenient syntax for converting a value to a given type. The slice
+// getters also accept a []interface{} whose items are all of the
+// proper type, as produced by the coercion of list arguments.
 type resultMap map[string]interface{}
 
 // Sets key to value.
@@ -32,6 +34,14 @@ func (m resultMap) GetAsString(key string) (ret string, ok bool) {
 func (m resultMap) GetAsStrings(key string) (ret []string, ok bool) {
 	if v, found := m[key]; found {
 		ret, ok = v.([]string)
+		if items, isList := v.([]interface{}); isList {
+			ret, ok = make([]string, len(items)), true
+			for i, item := range items {
+				if ret[i], ok = item.(string); !ok {
+					return nil, false
+				}
+			}
+		}
 	}
 	return
 }
@@ -52,6 +62,14 @@ func (m resultMap) GetAsInt(key string) (ret int, ok bool) {
 func (m resultMap) GetAsInts(key string) (ret []int, ok bool) {
 	if v, found := m[key]; found {
 		ret, ok = v.([]int)
+		if items, isList := v.([]interface{}); isList {
+			ret, ok = make([]int, len(items)), true
+			for i, item := range items {
+				if ret[i], ok = item.(int); !ok {
+					return nil, false
+				}
+			}
+		}
 	}
 	return
 }
@@ -72,6 +90,14 @@ func (m resultMap) GetAsFloat(key string) (ret float64, ok bool) {
 func (m resultMap) GetAsFloats(key string) (ret []float64, ok bool) {
 	if v, found := m[key]; found {
 		ret, ok = v.([]float64)
+		if items, isList := v.([]interface{}); isList {
+			ret, ok = make([]float64, len(items)), true
+			for i, item := range items {
+				if ret[i], ok = item.(float64); !ok {
+					return nil, false
+				}
+			}
+		}
 	}
 	return
 }
@@ -92,6 +118,43 @@ func (m resultMap) GetAsBool(key string) (ret bool, ok bool) {
 func (m resultMap) GetAsBools(key string) (ret []bool, ok bool) {
 	if v, found := m[key]; found {
 		ret, ok = v.([]bool)
+		if items, isList := v.([]interface{}); isList {
+			ret, ok = make([]bool, len(items)), true
+			for i, item := range items {
+				if ret[i], ok = item.(bool); !ok {
+					return nil, false
+				}
+			}
+		}
+	}
+	return
+}
+
+// Gets `key` from map and converts it to a map, as produced by the
+// coercion of input object arguments. Returns false as its second
+// argument if no value was present or the value was not of the proper
+// type.
+func (m resultMap) GetAsMap(key string) (ret map[string]interface{}, ok bool) {
+	if v, found := m[key]; found {
+		ret, ok = v.(map[string]interface{})
+	}
+	return
+}
+
+// Gets `key` from map and converts it to a slice of maps. Returns false
+// as its second argument if no value was present or the value was not
+// of the proper type.
+func (m resultMap) GetAsMaps(key string) (ret []map[string]interface{}, ok bool) {
+	if v, found := m[key]; found {
+		ret, ok = v.([]map[string]interface{})
+		if items, isList := v.([]interface{}); isList {
+			ret, ok = make([]map[string]interface{}, len(items)), true
+			for i, item := range items {
+				if ret[i], ok = item.(map[string]interface{}); !ok {
+					return nil, false
+				}
+			}
+		}
 	}
 	return
 }
@@ -0,0 +1,233 @@
+package schema
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"dylanmackenzie.com/graphql/ast"
+)
+
+var resultSchema = `
+enum Episode { NEWHOPE, EMPIRE, JEDI }
+
+type Character {
+  id: ID!
+  name: String!
+  height: Float
+  mass: Int
+  appearsIn: [Episode!]
+  friend: Character
+}
+
+type Query {
+  hero: Character
+}
+`
+
+func TestSetField(t *testing.T) {
+	height := 1.72
+	sch := newTestSchema(t, resultSchema, "Query", "")
+	sch.AddResolveFunc("Character", func(r *ResponseNode) {
+		for name, value := range map[string]interface{}{
+			"id":        int64(1000),
+			"name":      "Luke",
+			"height":    &height,
+			"appearsIn": []string{"NEWHOPE", "JEDI"},
+			"friend":    nil, // Not selected
+		} {
+			if err := r.SetField(name, value); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+	sch.Finalize()
+
+	res, err := executeQuery(t, sch, `{ hero { id name height appearsIn } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := res.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{"hero":{"id":"1000","name":"Luke","height":1.72,"appearsIn":["NEWHOPE","JEDI"]}}`
+	if string(actual) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, actual)
+	}
+}
+
+func TestSetFieldErrors(t *testing.T) {
+	var nilName *string
+	invalid := map[string]interface{}{
+		"weight":    10,
+		"name":      nilName,
+		"height":    "tall",
+		"appearsIn": []string{"CLONES"},
+		"id":        true,
+	}
+
+	sch := newTestSchema(t, resultSchema, "Query", "")
+	sch.AddResolveFunc("Character", func(r *ResponseNode) {
+		for name, value := range invalid {
+			if err := r.SetField(name, value); err == nil {
+				t.Errorf("Expected an error setting '%s' to %v", name, value)
+			}
+		}
+		r.Null(true)
+	})
+	sch.Finalize()
+
+	executeQuery(t, sch, `{ hero { id name height appearsIn } }`)
+}
+
+type character struct {
+	ID        int
+	Name      string
+	Height    *float64
+	Episodes  []string `graphql:"appearsIn"`
+	Friend    *character
+	Unrelated string
+}
+
+func TestSetObject(t *testing.T) {
+	luke := &character{ID: 1, Name: "Luke", Episodes: []string{"JEDI"}, Friend: &character{ID: 2, Name: "Han"}}
+
+	sch := newTestSchema(t, resultSchema, "Query", "")
+	sch.AddResolveFunc("Character", func(r *ResponseNode) {
+		if r.Name() == "friend" {
+			friend, _ := r.Parent().Get("friend")
+			if err := r.SetObject(friend); err != nil {
+				t.Error(err)
+			}
+			return
+		}
+
+		if err := r.SetObject(luke); err != nil {
+			t.Error(err)
+		}
+	})
+	sch.Finalize()
+
+	res, err := executeQuery(t, sch, `{ hero { id name height appearsIn friend { name } } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := res.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{"hero":{"id":"1","name":"Luke","height":null,"appearsIn":["JEDI"],"friend":{"name":"Han"}}}`
+	if string(actual) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, actual)
+	}
+
+	sch = newTestSchema(t, resultSchema, "Query", "")
+	sch.AddResolveFunc("Character", func(r *ResponseNode) {
+		if err := r.SetObject(map[string]interface{}{"name": []int{5}}); err == nil {
+			t.Error("Expected an error setting a list as a String")
+		}
+		if err := r.SetObject(5); err == nil {
+			t.Error("Expected an error setting a scalar as an object")
+		}
+		r.Null(true)
+	})
+	sch.Finalize()
+
+	executeQuery(t, sch, `{ hero { name } }`)
+}
+
+func TestScalarOutputCoercion(t *testing.T) {
+	types := New().types
+	valid := []struct {
+		typ    string
+		value  interface{}
+		expect interface{}
+	}{
+		{"Int", int64(42), 42},
+		{"Int", uint8(7), 7},
+		{"Int", 3.0, 3},
+		{"Float", float32(0.1), 0.1},
+		{"Float", 5, 5.0},
+		{"String", "luke", "luke"},
+		{"String", true, "true"},
+		{"String", 2.5, "2.5"},
+		{"ID", int64(1000), "1000"},
+		{"ID", "abc", "abc"},
+		{"Boolean", false, false},
+	}
+
+	for _, test := range valid {
+		def := types[test.typ].(*ast.ScalarDefinition)
+		actual, err := coerceScalarResult(reflect.ValueOf(test.value), def)
+		if err != nil || actual != test.expect {
+			t.Errorf("%s %v: Expected %v, got %v (%v)", test.typ, test.value, test.expect, actual, err)
+		}
+	}
+
+	invalid := []struct {
+		typ   string
+		value interface{}
+	}{
+		{"Int", int64(math.MaxInt32 + 1)},
+		{"Int", 1.5},
+		{"Int", float64(1 << 63)},
+		{"Int", float64(math.MaxInt32 + 1)},
+		{"Int", math.Inf(-1)},
+		{"Int", "1"},
+		{"Float", math.Inf(1)},
+		{"Float", "1.5"},
+		{"ID", 1.5},
+		{"ID", true},
+		{"Boolean", 1},
+		{"String", []string{}},
+	}
+
+	for _, test := range invalid {
+		def := types[test.typ].(*ast.ScalarDefinition)
+		if actual, err := coerceScalarResult(reflect.ValueOf(test.value), def); err == nil {
+			t.Errorf("%s %v: Expected an error, got %v", test.typ, test.value, actual)
+		}
+	}
+}
+
+func TestLeafFieldErrors(t *testing.T) {
+	sch := newTestSchema(t, resultSchema, "Query", "")
+	sch.AddResolveFunc("Character", func(r *ResponseNode) {
+		r.Set("name", "Luke")
+		r.Set("mass", uint64(1)<<40)
+	})
+	sch.Finalize()
+
+	res, err := executeQuery(t, sch, `{ hero { name mass height } }`)
+	if err == nil {
+		t.Error("Expected an error for the out of range mass and the unset height")
+	}
+
+	actual, _ := res.MarshalJSON()
+	if expect := `{"hero":{"name":"Luke","mass":null,"height":null}}`; string(actual) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, actual)
+	}
+
+	// A non-null field propagates the error to its parent
+	sch = newTestSchema(t, resultSchema, "Query", "")
+	sch.AddResolveFunc("Character", func(r *ResponseNode) {
+		r.Set("id", 1.5)
+		r.Set("name", "Luke")
+	})
+	sch.Finalize()
+
+	res, err = executeQuery(t, sch, `{ hero { id name } }`)
+	if err == nil {
+		t.Error("Expected an error for the invalid id")
+	}
+
+	actual, _ = res.MarshalJSON()
+	if expect := `{"hero":null}`; string(actual) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, actual)
+	}
+}
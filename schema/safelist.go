@@ -0,0 +1,220 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"dylanmackenzie.com/graphql/ast"
+)
+
+// An OperationManifest holds documents registered ahead of time, which
+// clients execute by giving the ID of a document as the documentId
+// parameter of a request in place of the document itself. Together with
+// HandlerOptions.StrictManifest, it limits a public endpoint to the
+// operations of known clients.
+type OperationManifest struct {
+	documents map[string]manifestDocument
+}
+
+type manifestDocument struct {
+	query string
+	doc   *ast.Document
+}
+
+// NewOperationManifest returns a manifest of the given documents, by ID.
+// Every document is parsed and each of its operations validated against
+// the schema, so that an invalid manifest is rejected before any request
+// is served.
+func NewOperationManifest(sch *Schema, documents map[string]string) (*OperationManifest, error) {
+	sch.Finalize()
+
+	// The documents are validated in order of ID, so that the same
+	// error is reported for an invalid manifest each time.
+	ids := make([]string, 0, len(documents))
+	for id := range documents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	m := &OperationManifest{documents: make(map[string]manifestDocument, len(documents))}
+	for _, id := range ids {
+		if err := m.add(sch, id, documents[id]); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// LoadOperationManifest reads a manifest from path, which is either a
+// JSON file holding an object of documents by ID, or a directory of
+// .graphql files each holding a document whose ID is the name of the
+// file without its extension.
+func LoadOperationManifest(sch *Schema, path string) (*OperationManifest, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		documents := make(map[string]string)
+		if err := json.Unmarshal(data, &documents); err != nil {
+			return nil, fmt.Errorf("Manifest '%s' must be a JSON object of documents by ID: %s", path, err)
+		}
+		return NewOperationManifest(sch, documents)
+	}
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := make(map[string]string)
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".graphql" {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(path, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		documents[strings.TrimSuffix(file.Name(), ".graphql")] = string(data)
+	}
+
+	return NewOperationManifest(sch, documents)
+}
+
+func (m *OperationManifest) add(sch *Schema, id, query string) error {
+	if id == "" {
+		return errors.New("Manifest contains a document without an ID")
+	}
+
+	doc, err := ast.FromReader(strings.NewReader(query))
+	if err != nil {
+		return fmt.Errorf("Document '%s': %s", id, err)
+	}
+
+	if err := validateDocument(sch, &doc); err != nil {
+		return fmt.Errorf("Document '%s': %s", id, err)
+	}
+
+	m.documents[id] = manifestDocument{query, &doc}
+	return nil
+}
+
+// manifestDocument returns the registered document of a request which
+// gives a document ID, or which must do so in strict mode. status is the
+// status of a response to a request which fails before execution.
+func (h *handler) manifestDocument(info *RequestInfo, status int) (*ast.Document, int, error) {
+	if h.opts.Manifest == nil {
+		return nil, http.StatusBadRequest, errors.New("Document IDs are not supported")
+	}
+
+	if info.DocumentID == "" {
+		return nil, status, errors.New("Only registered operations may be executed, by giving a documentId")
+	}
+	if info.Query != "" {
+		return nil, http.StatusBadRequest, errors.New("Request cannot give both a query and a documentId")
+	}
+
+	d, ok := h.opts.Manifest.documents[info.DocumentID]
+	if !ok {
+		return nil, status, fmt.Errorf("No document registered with ID '%s'", info.DocumentID)
+	}
+
+	// The query is recorded for the RequestLog
+	info.Query = d.query
+	return d.doc, 0, nil
+}
+
+// validateDocument checks each operation of a document against the
+// schema without executing it, returning every error found.
+func validateDocument(sch *Schema, doc *ast.Document) error {
+	var errs errorList
+	validated := make(map[string]bool)
+	for _, def := range doc.Definitions {
+		if op, ok := def.(*ast.OperationDefinition); ok && !validated[op.Name] {
+			validated[op.Name] = true
+			errs = append(errs, validateRegisteredOperation(sch, doc, op.Name)...)
+		}
+	}
+
+	// errorList separates its errors by newlines
+	if len(errs) > 0 {
+		return errors.New(strings.TrimSpace(errs.Error()))
+	}
+	return nil
+}
+
+// validateRegisteredOperation makes the checks of validateOperation
+// before the values of the operation's variables are known. Each
+// variable is only checked to be of an input type, and of a type
+// allowed wherever it is used.
+func validateRegisteredOperation(sch *Schema, doc *ast.Document, active string) errorList {
+	ctx := NewContext(sch)
+	ctx.lazyPanic = true
+
+	ctx.processDefinitions(doc, active)
+	if ctx.Operation == nil {
+		return ctx.Errors
+	}
+
+	ctx.getOperationRootType()
+	if ctx.Root == nil {
+		return ctx.Errors
+	}
+
+	for _, decl := range ctx.Operation.Variables {
+		if err := sch.checkInputType(decl.Type); err != nil {
+			ctx.addErrorf("Variable '$%s': %s", decl.Name, err)
+			continue
+		}
+		ctx.Variables[decl.Name] = coercedVariable{name: decl.Name, value: unknownValue{}, typ: decl.Type}
+	}
+
+	return ctx.validateOperation()
+}
+
+// An unknownValue is the value of a variable of an operation validated
+// before it is executed.
+type unknownValue struct{}
+
+// hasUnknownValue reports whether a literal holds a variable whose value
+// is not known.
+func hasUnknownValue(v ast.Value, ctx *executionContext) bool {
+	switch v := v.(type) {
+	case ast.VariableValue:
+		cv, _ := ctx.Variables[string(v)].(coercedVariable)
+		_, ok := cv.value.(unknownValue)
+		return ok
+
+	case ast.ListValue:
+		for _, item := range v {
+			if hasUnknownValue(item, ctx) {
+				return true
+			}
+		}
+
+	case ast.ObjectValue:
+		for _, item := range v {
+			if hasUnknownValue(item, ctx) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
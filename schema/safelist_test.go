@@ -0,0 +1,188 @@
+package schema
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOperationManifestInvalid(t *testing.T) {
+	for name, query := range map[string]string{
+		"Syntax":           `{ account { balance }`,
+		"UnknownField":     `{ account { interest } }`,
+		"UnknownArgument":  `mutation Deposit { deposit(amount: 5, currency: "EUR") { balance } }`,
+		"MissingSubFields": `{ account }`,
+		"LeafSubFields":    `{ account { balance { value } } }`,
+		"UnknownFragment":  `{ account { ...Details } }`,
+		"FragmentCycle":    `query Account { account { ...A } } fragment A on Account { owner { name } ...A }`,
+		"UnknownVariable":  `query Account($at: Time) { account { balance } }`,
+		"DuplicateOps":     `query A { account { balance } } query A { account { balance } }`,
+		"NoIntrospection":  `mutation Deposit { __schema { types { name } } }`,
+		"InlineFragment":   `{ account { ... on Owner { name } } }`,
+		"FragmentOnType":   `query Account { account { ...O } } fragment O on Owner { name }`,
+		"UnknownCondition": `{ account { ... on Person { name } } }`,
+		"InputCondition":   `{ account { ... on Int { name } } }`,
+		"ArgumentType":     `mutation Deposit { deposit(amount: "5") { balance } }`,
+		"VariableType":     `mutation Deposit($amount: String) { deposit(amount: $amount) { balance } }`,
+		"DirectiveType":    `{ account { balance @include(if: 1) } }`,
+		"FieldConflict":    `{ account { name: balance name: owner { name } } }`,
+	} {
+		sch := newTestSchema(t, bankSchema, "Query", "Mutation")
+		if _, err := NewOperationManifest(sch, map[string]string{name: query}); err == nil {
+			t.Errorf("%s: Expected an error", name)
+		} else if !strings.HasPrefix(err.Error(), "Document '"+name+"'") {
+			t.Errorf("%s: Expected the error to name the document, got '%s'", name, err)
+		}
+	}
+
+	// Documents are validated in order of ID
+	sch := newTestSchema(t, bankSchema, "Query", "Mutation")
+	documents := map[string]string{"C": `{ account }`, "A": `{ account }`, "B": `{ account }`}
+	for i := 0; i < 10; i++ {
+		if _, err := NewOperationManifest(sch, documents); err == nil || !strings.HasPrefix(err.Error(), "Document 'A'") {
+			t.Fatalf("Expected the error of the first document, got '%v'", err)
+		}
+	}
+}
+
+var petSchema = `
+interface Pet {
+  name: String!
+}
+
+type Dog : Pet {
+  name: String!
+  barkVolume: Int
+}
+
+type Cat : Pet {
+  name: String!
+  meowVolume: Int
+}
+
+type Query {
+  pet: Pet
+  dog: Dog
+}
+`
+
+func TestOperationManifestFragments(t *testing.T) {
+	sch := newTestSchema(t, petSchema, "Query", "")
+
+	// The fields of a fragment are those of the type of the field it is
+	// selected on, however narrow its type condition
+	valid := `query Pets($named: Boolean!) {
+		pet { name @include(if: $named) ... on Dog { name } ...CatFields }
+		dog { ... on Pet { name } ... on Dog { barkVolume } }
+	}
+	fragment CatFields on Cat { name }`
+	if _, err := NewOperationManifest(sch, map[string]string{"Pets": valid}); err != nil {
+		t.Error(err)
+	}
+
+	for name, query := range map[string]string{
+		"WrongCondition":   `{ pet { ... on Dog { meowVolume } } }`,
+		"NeverApplies":     `{ dog { ... on Cat { name } } }`,
+		"UnknownCondition": `{ pet { ... on Person { name } } }`,
+	} {
+		if _, err := NewOperationManifest(sch, map[string]string{name: query}); err == nil {
+			t.Errorf("%s: Expected an error", name)
+		}
+	}
+}
+
+func TestLoadOperationManifest(t *testing.T) {
+	sch := newTestSchema(t, bankSchema, "Query", "Mutation")
+
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"Balance.graphql":  `query Balance { account { balance ...Owner } } fragment Owner on Account { owner { name } }`,
+		"Deposit.graphql":  `mutation Deposit($amount: Int) { deposit(amount: $amount) { balance } }`,
+		"README.md":        `Not a document`,
+		"manifest.json":    `{"Balance": "{ account { balance } }"}`,
+		"invalid.json":     `["{ account { balance } }"]`,
+		"Schema.graphql.1": `{ account { interest } }`,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := LoadOperationManifest(sch, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.documents) != 2 || m.documents["Balance"].doc == nil || m.documents["Deposit"].doc == nil {
+		t.Errorf("Expected the documents of the .graphql files, got %v", m.documents)
+	}
+
+	if m, err := LoadOperationManifest(sch, filepath.Join(dir, "manifest.json")); err != nil {
+		t.Error(err)
+	} else if _, ok := m.documents["Balance"]; !ok || len(m.documents) != 1 {
+		t.Errorf("Expected the documents of the JSON file, got %v", m.documents)
+	}
+
+	for _, name := range []string{"invalid.json", "missing.json"} {
+		if _, err := LoadOperationManifest(sch, filepath.Join(dir, name)); err == nil {
+			t.Errorf("%s: Expected an error", name)
+		}
+	}
+}
+
+func TestHandlerManifest(t *testing.T) {
+	sch := newTestSchema(t, bankSchema, "Query", "")
+	sch.AddResolveFunc("Account", func(r *ResponseNode) {
+		r.Set("balance", 10)
+	})
+
+	m, err := NewOperationManifest(sch, map[string]string{"Balance": `query Balance { account { balance } }`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		strict bool
+		params url.Values
+		status int
+	}{
+		{false, url.Values{"documentId": {"Balance"}, "operationName": {"Balance"}}, http.StatusOK},
+		{true, url.Values{"documentId": {"Balance"}, "operationName": {"Balance"}}, http.StatusOK},
+		{false, url.Values{"query": {"{ account { balance } }"}}, http.StatusOK},
+		{true, url.Values{"query": {"{ account { balance } }"}}, http.StatusBadRequest},
+		{true, url.Values{"documentId": {"Transfer"}}, http.StatusBadRequest},
+		{false, url.Values{"documentId": {"Balance"}, "query": {"{ account { balance } }"}}, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		h := sch.HandlerWithOptions(HandlerOptions{Manifest: m, StrictManifest: test.strict})
+
+		req := httptest.NewRequest("GET", "/graphql?"+test.params.Encode(), nil)
+		req.Header.Set("Accept", mediaTypeGraphQLResponse)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if w.Code != test.status {
+			t.Errorf("%v, %v: Expected status %d, got %d: %s", test.strict, test.params, test.status, w.Code, w.Body)
+		}
+		if test.status == http.StatusOK && w.Body.String() != `{"data":{"account":{"balance":10}}}` {
+			t.Errorf("%v, %v: Unexpected response '%s'", test.strict, test.params, w.Body)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	sch.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/graphql?documentId=Balance", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected document IDs to be unsupported without a manifest, got status %d", w.Code)
+	}
+}
@@ -0,0 +1,145 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+
+	"dylanmackenzie.com/graphql/ast"
+)
+
+// A Scalar provides the functions which convert the values of a custom
+// scalar type, declared in the schema without a base type:
+//
+//	scalar DateTime @specifiedBy(url: "https://tools.ietf.org/html/rfc3339")
+//
+// Each function returns an error describing why a value is invalid.
+type Scalar struct {
+	// ParseLiteral converts a value written in a document to the Go
+	// value passed to resolvers. If nil, the literal is converted to
+	// the value it would have if decoded from JSON and passed to
+	// ParseValue.
+	ParseLiteral func(v ast.Value) (interface{}, error)
+
+	// ParseValue converts a value decoded from the JSON variables of a
+	// request to the Go value passed to resolvers. Numbers are given as
+	// json.Number, or as float64 if the variables were decoded by the
+	// caller of ExecuteWithVariables.
+	ParseValue func(v interface{}) (interface{}, error)
+
+	// Serialize converts a value set by a resolver to a value which is
+	// encoded as JSON in the response.
+	Serialize func(v interface{}) (interface{}, error)
+}
+
+// AddScalar provides the functions for the named scalar type, which
+// must already be declared in the schema. Scalars declared with a base
+// type may also be given functions, which replace the coercion rules of
+// that type, but the built-in scalars may not.
+func (sch *Schema) AddScalar(name string, s Scalar) {
+	if !sch.mutable {
+		panic("Attempted to mutate schema after it has been finalized")
+	}
+
+	def, ok := sch.types[name]
+	if !ok {
+		log.Panicf("No type named '%s' found", name)
+	}
+
+	if _, ok := def.(*ast.ScalarDefinition); !ok {
+		log.Panicf("Attempting to add scalar functions to non-scalar type '%s'", name)
+	}
+
+	switch name {
+	case "Int", "Float", "String", "Boolean", "ID":
+		log.Panicf("Cannot replace built-in scalar '%s'", name)
+	}
+
+	if s.ParseValue == nil || s.Serialize == nil {
+		log.Panicf("Scalar '%s' must provide ParseValue and Serialize", name)
+	}
+
+	sch.scalars[name] = &s
+}
+
+// scalar returns the functions of a custom scalar, if it has any. sch
+// may be nil.
+func (sch *Schema) scalar(name string) (*Scalar, bool) {
+	if sch == nil {
+		return nil, false
+	}
+
+	s, ok := sch.scalars[name]
+	return s, ok
+}
+
+// parseLiteral converts a literal given to a custom scalar.
+func (s *Scalar) parseLiteral(v ast.Value, ctx *executionContext) (interface{}, error) {
+	// A literal cannot be parsed until the values of its variables are
+	// known.
+	if hasUnknownValue(v, ctx) {
+		return unknownValue{}, nil
+	}
+
+	if s.ParseLiteral != nil {
+		return s.ParseLiteral(v)
+	}
+
+	return s.ParseValue(jsonValue(v, ctx))
+}
+
+// jsonValue returns the value which a literal would have if it had been
+// decoded from JSON, substituting variables.
+func jsonValue(v ast.Value, ctx *executionContext) interface{} {
+	switch v := v.(type) {
+	case ast.VariableValue:
+		// Variables have already been coerced to their declared type,
+		// and are passed on as they are instead of being parsed again
+		value, ok := ctx.Variables[string(v)]
+		if !ok {
+			return nil
+		}
+		return value.Value()
+
+	case ast.IntValue:
+		return json.Number(strconv.Itoa(int(v)))
+	case ast.FloatValue:
+		return json.Number(strconv.FormatFloat(float64(v), 'g', -1, 64))
+	case ast.StringValue:
+		if s, err := v.Unescape(); err == nil {
+			return s
+		}
+	case ast.EnumValue:
+		return string(v)
+
+	case ast.ListValue:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = jsonValue(item, ctx)
+		}
+		return out
+
+	case ast.ObjectValue:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = jsonValue(item, ctx)
+		}
+		return out
+
+	case nil:
+		return nil
+	}
+
+	return v.Value()
+}
+
+// serialize converts a value set by a resolver for a custom scalar.
+func (s *Scalar) serialize(v interface{}, name string) (interface{}, error) {
+	out, err := s.Serialize(v)
+	if err != nil {
+		return nil, fmt.Errorf("Value %v is not a valid '%s': %s", v, name, err)
+	}
+
+	return out, nil
+}
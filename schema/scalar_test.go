@@ -0,0 +1,149 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"dylanmackenzie.com/graphql/ast"
+)
+
+var scalarSchema = `
+scalar DateTime @specifiedBy(url: "https://tools.ietf.org/html/rfc3339")
+
+type Event {
+  name: String
+  at: DateTime!
+}
+
+type Query {
+  next(after: DateTime!): Event
+}
+`
+
+var dateTime = Scalar{
+	ParseValue: func(v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("DateTime must be a string")
+		}
+		return time.Parse(time.RFC3339, s)
+	},
+	Serialize: func(v interface{}) (interface{}, error) {
+		t, ok := v.(time.Time)
+		if !ok {
+			return nil, errors.New("DateTime must be a time.Time")
+		}
+		return t.UTC().Format(time.RFC3339), nil
+	},
+}
+
+// resolveEvent resolves the next event to an hour after its argument.
+func resolveEvent(r *ResponseNode) {
+	after, _ := r.Args.Get("after")
+	r.Set("name", "launch")
+	r.Set("at", after.(time.Time).Add(time.Hour))
+}
+
+func TestCustomScalar(t *testing.T) {
+	sch := newTestSchema(t, scalarSchema, "Query", "")
+	sch.AddScalar("DateTime", dateTime)
+	sch.AddResolveFunc("Event", resolveEvent)
+	sch.Finalize()
+
+	res, err := executeQuery(t, sch, `{ next(after: "2016-01-02T15:04:05Z") { name at } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := res.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expect := `{"next":{"name":"launch","at":"2016-01-02T16:04:05Z"}}`; string(actual) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, actual)
+	}
+
+	for _, query := range []string{
+		`{ next(after: "yesterday") { at } }`,
+		`{ next(after: 5) { at } }`,
+	} {
+		if _, err := executeQuery(t, sch, query); err == nil {
+			t.Errorf("%s: Expected an error", query)
+		}
+	}
+}
+
+func TestCustomScalarSetField(t *testing.T) {
+	sch := newTestSchema(t, scalarSchema, "Query", "")
+	sch.AddScalar("DateTime", dateTime)
+	sch.AddResolveFunc("Event", func(r *ResponseNode) {
+		if err := r.SetField("at", time.Date(2016, 1, 2, 15, 4, 5, 0, time.UTC)); err != nil {
+			t.Error(err)
+		}
+		if err := r.SetField("at", "tomorrow"); err == nil {
+			t.Error("Expected an error setting a string as a DateTime")
+		}
+	})
+	sch.Finalize()
+
+	res, err := executeQuery(t, sch, `{ next(after: "2016-01-02T15:04:05Z") { at } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual, _ := res.MarshalJSON(); string(actual) != `{"next":{"at":"2016-01-02T15:04:05Z"}}` {
+		t.Errorf("Expected the value to be serialized once, got '%s'", actual)
+	}
+}
+
+func TestCustomScalarSerializeError(t *testing.T) {
+	sch := newTestSchema(t, scalarSchema, "Query", "")
+	sch.AddScalar("DateTime", dateTime)
+	sch.AddResolveFunc("Event", func(r *ResponseNode) {
+		r.Set("at", "tomorrow")
+	})
+	sch.Finalize()
+
+	res, err := executeQuery(t, sch, `{ next(after: "2016-01-02T15:04:05Z") { at } }`)
+	if err == nil {
+		t.Error("Expected an error serializing a string as a DateTime")
+	}
+
+	if actual, _ := res.MarshalJSON(); string(actual) != `{"next":null}` {
+		t.Errorf("Expected the non-null error to null the event, got '%s'", actual)
+	}
+}
+
+func TestCustomScalarDefinition(t *testing.T) {
+	sch := newTestSchema(t, scalarSchema, "Query", "")
+	if def := sch.types["DateTime"].(*ast.ScalarDefinition); def.SpecifiedBy != "https://tools.ietf.org/html/rfc3339" {
+		t.Errorf("Expected @specifiedBy to be recorded, got '%s'", def.SpecifiedBy)
+	}
+
+	func() {
+		defer shouldPanic("Replacing a built-in scalar", t)
+		sch.AddScalar("Int", dateTime)
+	}()
+
+	defer shouldPanic("Custom scalar without functions", t)
+	sch.Finalize()
+}
+
+func TestSpecifiedByIntrospection(t *testing.T) {
+	sch := newTestSchema(t, scalarSchema, "Query", "")
+	sch.AddScalar("DateTime", dateTime)
+	sch.AddResolveFunc("Event", resolveEvent)
+	sch.Finalize()
+
+	res, err := executeQuery(t, sch, `{ __type(name: "DateTime") { kind specifiedByURL } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, _ := res.MarshalJSON()
+	if expect := `{"__type":{"kind":"SCALAR","specifiedByURL":"https://tools.ietf.org/html/rfc3339"}}`; string(actual) != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, actual)
+	}
+}
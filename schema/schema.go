@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"dylanmackenzie.com/graphql/ast"
+	"dylanmackenzie.com/graphql/dataloader"
 )
 
 // A Schema represents an entire GraphQL type system which can be
@@ -13,18 +14,33 @@ import (
 // construction of the schema, we panic as there is no way to rectify
 // an invalid schema.
 type Schema struct {
-	types        map[string]ast.TypeDefinition // The types known by the schema
-	resolvers    map[string]Resolver           // The resolvers
+	types        map[string]ast.TypeDefinition   // The types known by the schema
+	resolvers    map[string]Resolver             // The resolvers
+	loaders      map[string]dataloader.BatchFunc // The batch functions of each request's loaders
+	scalars      map[string]*Scalar              // The functions of custom scalars
+	enums        map[string]*enumBinding         // The Go values bound to enums
 	QueryRoot    *ast.ObjectDefinition
 	MutationRoot *ast.ObjectDefinition
 
+	// NewExecutor is called once per request to create the Executor
+	// which schedules its fields. If nil, ParallelExecutor is used.
+	NewExecutor func() Executor
+
+	// The introspection fields which are not declared by any type,
+	// and the declarations of the directives.
+	meta       *ast.ObjectDefinition
+	directives *ast.ObjectDefinition
+
 	mutable bool // Flag set to false after the schema has been finalized
 }
 
 func New() *Schema {
-	// Every schema requires the scalar types
-	return &Schema{
+	// Every schema requires the scalar and introspection types
+	sch := &Schema{
 		resolvers: make(map[string]Resolver),
+		loaders:   make(map[string]dataloader.BatchFunc),
+		scalars:   make(map[string]*Scalar),
+		enums:     make(map[string]*enumBinding),
 		types: map[string]ast.TypeDefinition{
 			"Int":     &ast.ScalarDefinition{Name: "Int", Kind: reflect.Int},
 			"Float":   &ast.ScalarDefinition{Name: "Float", Kind: reflect.Float64},
@@ -34,12 +50,26 @@ func New() *Schema {
 		},
 		mutable: true,
 	}
+
+	sch.addIntrospection()
+	return sch
 }
 
+// executor creates the Executor for a single request.
+func (sch *Schema) executor() Executor {
+	if sch.NewExecutor == nil {
+		return ParallelExecutor()
+	}
+
+	return sch.NewExecutor()
+}
+
+// resolver returns the resolver for the named type, or DefaultResolver
+// if none was added.
 func (sch *Schema) resolver(name string) Resolver {
 	res, ok := sch.resolvers[name]
 	if !ok {
-		log.Panicf("No resolver for type '%s' found", name)
+		return DefaultResolver
 	}
 
 	return res
@@ -63,12 +93,23 @@ func (sch *Schema) AddResolveFunc(name string, res ResolveFunc) {
 	sch.AddResolver(name, Resolver(res))
 }
 
+// AddLoader registers the batch function used to load values of the
+// named type. Every request creates its own Loader from it, which
+// resolvers can retrieve with ResponseNode.Loader.
+func (sch *Schema) AddLoader(name string, batch dataloader.BatchFunc) {
+	if _, ok := sch.types[name]; !ok {
+		log.Panicf("No type named '%s' found", name)
+	}
+
+	sch.loaders[name] = batch
+}
+
 // definition takes a result type and finds its definition in
 // the schema. It panics if the referenced type is not found.
 func (sch *Schema) definition(t *ast.BaseType) ast.TypeDefinition {
 	def, ok := sch.types[t.Name()]
 	if !ok {
-		log.Panicf("Type '%s' not found in schema", t.Name)
+		log.Panicf("Type '%s' not found in schema", t.Name())
 	}
 	return def
 }
@@ -96,6 +137,44 @@ func (sch *Schema) verify(desc ast.TypeDescriptor) {
 
 }
 
+// verifyInputValue ensures that an argument or input object field,
+// declared by the given owner, is of an input type and that its default
+// value, if it has one, is of that type.
+func (sch *Schema) verifyInputValue(arg ast.ArgumentDeclaration, owner string) {
+	sch.verify(arg.Type)
+
+	if def := sch.baseDefinition(arg.Type); def != nil && ast.IsAbstractType(def) {
+		log.Panicf("Type '%s' of '%s' in %s is not an input type", def.TypeName(), arg.Key, owner)
+	}
+
+	if arg.Default == nil {
+		return
+	}
+
+	if _, err := coerceValue(arg.Default, arg.Type, NewContext(sch)); err != nil {
+		log.Panicf("Invalid default value for '%s' in %s: %s", arg.Key, owner, err)
+	}
+}
+
+// verifyOutput ensures that the type of a field is not an input object.
+func (sch *Schema) verifyOutput(desc ast.TypeDescriptor) {
+	def := sch.baseDefinition(desc)
+	if _, ok := def.(*ast.InputObjectDefinition); ok || def == nil {
+		log.Panicf("Type '%s' is not an output type", desc.Name())
+	}
+}
+
+// baseDefinition returns the definition of the named type underlying a
+// type descriptor, or nil for an anonymous input object.
+func (sch *Schema) baseDefinition(desc ast.TypeDescriptor) ast.TypeDefinition {
+	base := ast.GetBaseType(desc)
+	if base == nil {
+		return nil
+	}
+
+	return sch.definition(base)
+}
+
 // finalize ensures that every type referenced in the schema actually
 // exists in the schema. It is called once all types have been added to
 // the schema but before the schema is used. Once the type checking is
@@ -108,17 +187,20 @@ func (sch *Schema) Finalize() {
 		panic("Schema must provide a root object for queries. Call schema.Root(\"query\", name).")
 	}
 
+	sch.finalizeIntrospection()
+
 	for _, def := range sch.types {
 		switch t := def.(type) {
 		case *ast.ObjectDefinition:
 			for i, field := range t.Fields {
 				sch.verify(field.Type)
+				sch.verifyOutput(field.Type)
 
 				// Cache pointer to definition in TypeField
 				t.Fields[i].Definition = sch.definition(ast.GetBaseType(field.Type))
 
 				for _, arg := range field.Arguments {
-					sch.verify(arg.Type)
+					sch.verifyInputValue(arg, "field '"+field.Name+"'")
 				}
 			}
 
@@ -134,28 +216,43 @@ func (sch *Schema) Finalize() {
 			}
 
 		case *ast.InterfaceDefinition:
-			for _, field := range t.Fields {
+			for i, field := range t.Fields {
 				sch.verify(field.Type)
+				sch.verifyOutput(field.Type)
+				t.Fields[i].Definition = sch.definition(ast.GetBaseType(field.Type))
+
 				for _, arg := range field.Arguments {
-					sch.verify(arg.Type)
+					sch.verifyInputValue(arg, "field '"+field.Name+"'")
 				}
 			}
 
+		case *ast.InputObjectDefinition:
+			for _, field := range t.Fields {
+				sch.verifyInputValue(field, "input '"+t.Name+"'")
+			}
+
 		case *ast.UnionDefinition:
 			for _, member := range t.Members {
 				sch.verify(member)
 			}
 
 		case *ast.ScalarDefinition:
+			if _, ok := sch.scalars[t.Name]; ok {
+				continue
+			}
+
 			switch t.Kind {
 			case reflect.Int, reflect.Bool, reflect.Float64, reflect.String:
 				continue
+			case reflect.Invalid:
+				log.Panicf("Custom scalar '%s' has no functions. Call schema.AddScalar(\"%s\", ...).", t.Name, t.Name)
 			default:
 				panic("ScalarDefinition has invalid underlying type")
 			}
 
 		case *ast.EnumDefinition:
-			// All enums are of type Int
+			// Enums are represented by the names of their values,
+			// unless bound to Go values by AddEnum.
 			continue
 
 		default:
@@ -206,6 +303,10 @@ func (sch *Schema) addType(def ast.TypeDefinition) {
 		name = t.Name
 		assertFieldsUnique(t.Fields, t.Name)
 
+	case *ast.InputObjectDefinition:
+		name = t.Name
+		assertInputFieldsUnique(t.Fields, t.Name)
+
 	case *ast.UnionDefinition:
 		name = t.Name
 		if len(t.Members) == 0 {
@@ -267,3 +368,7 @@ func AddResolver(name string, res Resolver) {
 func AddResolveFunc(name string, res ResolveFunc) {
 	def.AddResolveFunc(name, res)
 }
+
+func AddLoader(name string, batch dataloader.BatchFunc) {
+	def.AddLoader(name, batch)
+}
@@ -44,6 +44,10 @@ type Cat : Pet {
 union CatOrDog = Cat | Dog
 union DogOrHuman = Dog | Human
 union HumanOrAlien = Human | Alien
+
+type Query {
+  dog: Dog
+}
 `
 
 var result = map[string][]string{
@@ -73,6 +77,7 @@ func TestAddDocument(t *testing.T) {
 
 	sch := New()
 	sch.AddDocument(&doc)
+	sch.Root("query", "Query")
 	sch.Finalize()
 
 	for name, fields := range result {
@@ -22,6 +22,18 @@ func assertFieldsUnique(fields []ast.TypeField, desc string) {
 	}
 }
 
+// Assert that every field name of an input object is unique. Panics if
+// the assertion fails.
+func assertInputFieldsUnique(fields ast.ArgumentDeclarations, desc string) {
+	found := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if found[field.Key] {
+			log.Panicf("Multiple fields named '%s' in '%s'", field.Key, desc)
+		}
+		found[field.Key] = true
+	}
+}
+
 // Assert that an object implements an interface. Panics if the
 // assertion fails.
 func assertObjectImplements(obj *ast.ObjectDefinition, iface *ast.InterfaceDefinition) {
@@ -62,7 +74,7 @@ func assertArgumentsCompatible(f1, f2 ast.Field) {
 
 		if arg1.Key != arg2.Key {
 			log.Panicf(
-				"Field '%s' has argument named '%s' of type '%s', but argument in Field '%s' is of type %s",
+				"Field '%s' has argument %d named '%s', but argument in Field '%s' is named '%s'",
 				f1.Name, i, arg1.Key, f2.Name, arg2.Key)
 		}
 	}
@@ -80,7 +92,7 @@ func assertArgumentDeclarationsCompatible(f1, f2 ast.TypeField) {
 
 		if t1.Key != t2.Key {
 			log.Panicf(
-				"Field '%s' has argument named '%s' of type '%s', but argument in Field '%s' is of type %s",
+				"Field '%s' has argument %d named '%s', but argument in Field '%s' is named '%s'",
 				f1.Name, i, t1.Key, f2.Name, t2.Key)
 		}
 	}
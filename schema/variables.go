@@ -0,0 +1,346 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+
+	"dylanmackenzie.com/graphql/ast"
+)
+
+// A coercedVariable is the value of a variable after coercion, which
+// takes the place of the variable in the document.
+type coercedVariable struct {
+	name  string
+	value interface{}
+	typ   ast.TypeDescriptor // The type declared by the operation
+}
+
+func (v coercedVariable) Value() interface{} { return v.value }
+
+// ExecuteWithVariables is like Execute, but provides the values of the
+// variables declared by the operation, as decoded from the JSON
+// variables of a request. Numbers may be given as float64 or, to keep
+// the digits of large IDs, as json.Number.
+func ExecuteWithVariables(c context.Context, sch *Schema, doc *ast.Document, active string, vars map[string]interface{}) (*executionContext, error) {
+	return executeRequest(c, sch, doc, active, executeOptions{variables: vars})
+}
+
+// coerceVariables coerces the values of the variables declared by the
+// active operation and stores them in ctx.Variables, returning an error
+// for each variable which cannot be coerced. The values, as decoded
+// from JSON, become the same Go values as a literal of their type:
+//
+//	Int            int, from a number without a fractional part which
+//	               fits in 32 bits
+//	Float          float64, from any number
+//	String         string
+//	ID             string, from a string or an integral number
+//	Boolean        bool
+//	Enum           the Go value bound by AddEnum, or otherwise a string,
+//	               from a string naming the enum value
+//	Custom scalar  the result of the scalar's ParseValue
+//	List           []interface{}, from an array or a single item
+//	Input Object   map[string]interface{}, from an object
+//
+// A variable which is not provided takes its default value, and is
+// omitted if it has none, in which case arguments given the variable
+// take their own default value. A variable explicitly set to null is
+// null.
+func (ctx *executionContext) coerceVariables(vars map[string]interface{}) errorList {
+	errs := errorList{}
+	for _, decl := range ctx.Operation.Variables {
+		v, err := ctx.coerceVariable(decl, vars)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Variable '$%s': %s", decl.Name, err))
+			continue
+		}
+		if v != nil {
+			ctx.Variables[decl.Name] = *v
+		}
+	}
+
+	return errs
+}
+
+// coerceVariable coerces the value of a single variable, returning nil
+// if it is omitted.
+func (ctx *executionContext) coerceVariable(decl ast.Variable, vars map[string]interface{}) (*coercedVariable, error) {
+	if err := ctx.Schema.checkInputType(decl.Type); err != nil {
+		return nil, err
+	}
+
+	out := &coercedVariable{name: decl.Name, typ: decl.Type}
+
+	v, ok := vars[decl.Name]
+	switch {
+	case ok:
+		var err error
+		out.value, err = coerceVariableValue(v, decl.Type, ctx)
+		return out, err
+
+	case decl.Default != nil:
+		var err error
+		out.value, err = coerceValue(decl.Default, decl.Type, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid default value: %s", err)
+		}
+		return out, nil
+
+	case !decl.Type.Nullable():
+		return nil, fmt.Errorf("Value of non-nullable type '%s' was not provided", decl.Type.Name())
+	}
+
+	return nil, nil
+}
+
+// checkInputType returns an error if desc is not an input type of the
+// schema.
+func (sch *Schema) checkInputType(desc ast.TypeDescriptor) error {
+	if base := ast.GetBaseType(desc); base != nil {
+		def, ok := sch.types[base.Name()]
+		if !ok {
+			return fmt.Errorf("Type '%s' not found in schema", base.Name())
+		}
+		if ast.IsAbstractType(def) {
+			return fmt.Errorf("Type '%s' is not an input type", base.Name())
+		}
+	}
+
+	return nil
+}
+
+// coerceVariableValue converts a value decoded from JSON to the Go
+// representation of the given input type.
+func coerceVariableValue(v interface{}, desc ast.TypeDescriptor, ctx *executionContext) (interface{}, error) {
+	if v == nil {
+		if !desc.Nullable() {
+			return nil, fmt.Errorf("Null value for non-nullable type '%s'", desc.Name())
+		}
+		return nil, nil
+	}
+
+	switch t := desc.(type) {
+	case *ast.ListType:
+		// A single value is coerced to a list of one item
+		list, ok := v.([]interface{})
+		if !ok {
+			list = []interface{}{v}
+		}
+
+		out := make([]interface{}, len(list))
+		for i, item := range list {
+			var err error
+			if out[i], err = coerceVariableValue(item, t.OfType, ctx); err != nil {
+				return nil, fmt.Errorf("Item %d: %s", i, err)
+			}
+		}
+		return out, nil
+
+	case *ast.InputObjectType:
+		return coerceVariableObject(v, anonymousFields(t), ctx)
+
+	case *ast.BaseType:
+		def, ok := ctx.Schema.types[t.Name()]
+		if !ok {
+			return nil, fmt.Errorf("Type '%s' not found in schema", t.Name())
+		}
+
+		if obj, ok := def.(*ast.InputObjectDefinition); ok {
+			return coerceVariableObject(v, obj.Fields, ctx)
+		}
+		return coerceVariableLeaf(v, def, ctx)
+	}
+
+	return nil, errors.New("Invalid input type")
+}
+
+// coerceVariableObject coerces a JSON object to the given input object
+// fields, applying their defaults.
+func coerceVariableObject(v interface{}, fields ast.ArgumentDeclarations, ctx *executionContext) (interface{}, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Expected an input object, got %s", describeJSON(v))
+	}
+
+	for key := range obj {
+		if _, ok := findArgumentDeclaration(fields, key); !ok {
+			return nil, fmt.Errorf("Unknown input object field '%s'", key)
+		}
+	}
+
+	out := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		item, ok := obj[field.Key]
+		if !ok {
+			if field.Default != nil {
+				coerced, err := coerceValue(field.Default, field.Type, ctx)
+				if err != nil {
+					return nil, fmt.Errorf("Input object field '%s': %s", field.Key, err)
+				}
+				out[field.Key] = coerced
+			} else if !field.Type.Nullable() {
+				return nil, fmt.Errorf("Input object field '%s' of type '%s' is required", field.Key, field.Type.Name())
+			}
+			continue
+		}
+
+		coerced, err := coerceVariableValue(item, field.Type, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Input object field '%s': %s", field.Key, err)
+		}
+		out[field.Key] = coerced
+	}
+
+	return out, nil
+}
+
+// coerceVariableLeaf coerces a JSON value to a scalar or enum type.
+func coerceVariableLeaf(v interface{}, def ast.TypeDefinition, ctx *executionContext) (interface{}, error) {
+	switch t := def.(type) {
+	case *ast.EnumDefinition:
+		name, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("Expected a value of enum '%s', got %s", t.Name, describeJSON(v))
+		}
+		return ctx.Schema.parseEnum(name, t)
+
+	case *ast.ScalarDefinition:
+		if s, ok := ctx.Schema.scalar(t.Name); ok {
+			out, err := s.ParseValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid value %s for '%s': %s", describeJSON(v), t.Name, err)
+			}
+			return out, nil
+		}
+		return coerceScalarVariable(v, t)
+	}
+
+	return nil, fmt.Errorf("Type '%s' is not an input type", def.TypeName())
+}
+
+func coerceScalarVariable(v interface{}, def *ast.ScalarDefinition) (interface{}, error) {
+	switch value := v.(type) {
+	case json.Number:
+		// IDs keep every digit of integers too large for a float64
+		if _, ok := new(big.Int).SetString(value.String(), 10); ok && def.Name == "ID" {
+			return value.String(), nil
+		}
+
+		f, err := value.Float64()
+		if err != nil {
+			break
+		}
+		return coerceScalarVariable(f, def)
+
+	case float64:
+		switch {
+		case def.Kind == reflect.Float64:
+			return value, nil
+		case value != math.Trunc(value) || math.IsInf(value, 0):
+		case def.Kind == reflect.Int:
+			if value < math.MinInt32 || value > math.MaxInt32 {
+				return nil, fmt.Errorf("Value %g does not fit in a 32-bit '%s'", value, def.Name)
+			}
+			return int(value), nil
+		case def.Name == "ID":
+			return strconv.FormatFloat(value, 'f', -1, 64), nil
+		}
+
+	case string:
+		if def.Kind == reflect.String {
+			return value, nil
+		}
+
+	case bool:
+		if def.Kind == reflect.Bool {
+			return value, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Expected type '%s', got %s", def.Name, describeJSON(v))
+}
+
+// describeJSON describes a value decoded from JSON for use in error
+// messages.
+func describeJSON(v interface{}) string {
+	switch v := v.(type) {
+	case json.Number:
+		return fmt.Sprintf("number %s", v)
+	case float64:
+		return fmt.Sprintf("number %g", v)
+	case string:
+		return fmt.Sprintf("string \"%s\"", v)
+	case bool:
+		return fmt.Sprintf("boolean %t", v)
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	case nil:
+		return "null"
+	}
+
+	return fmt.Sprintf("%v", v)
+}
+
+// useAs returns the coerced value of a variable given where a
+// value of type desc is expected.
+func (v coercedVariable) useAs(desc ast.TypeDescriptor) (interface{}, error) {
+	if v.value == nil {
+		if !desc.Nullable() {
+			return nil, fmt.Errorf("Null value of variable '$%s' for non-nullable type '%s'", v.name, desc.Name())
+		}
+		return nil, nil
+	}
+
+	if !variableTypeAllowed(v.typ, desc) {
+		return nil, fmt.Errorf("Variable '$%s' of type '%s' cannot be used as type '%s'", v.name, typeString(v.typ), typeString(desc))
+	}
+
+	return v.value, nil
+}
+
+// variableTypeAllowed reports whether a variable of type v may be given
+// where a value of type loc is expected. The nullability of the
+// outermost types is not compared, since a null value is rejected when
+// the variable is used.
+func variableTypeAllowed(v, loc ast.TypeDescriptor) bool {
+	switch l := loc.(type) {
+	case *ast.ListType:
+		t, ok := v.(*ast.ListType)
+		if !ok || t.OfType.Nullable() && !l.OfType.Nullable() {
+			return false
+		}
+		return variableTypeAllowed(t.OfType, l.OfType)
+
+	case *ast.BaseType:
+		t, ok := v.(*ast.BaseType)
+		return ok && t.Name() == l.Name()
+
+	case *ast.InputObjectType:
+		_, ok := v.(*ast.InputObjectType)
+		return ok
+	}
+
+	return false
+}
+
+// typeString writes a type as it is written in a document, including
+// whether it is nullable.
+func typeString(desc ast.TypeDescriptor) string {
+	s := desc.Name()
+	if list, ok := desc.(*ast.ListType); ok {
+		s = "[" + typeString(list.OfType) + "]"
+	}
+
+	if !desc.Nullable() {
+		s += "!"
+	}
+	return s
+}
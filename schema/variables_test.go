@@ -0,0 +1,339 @@
+package schema
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"dylanmackenzie.com/graphql/ast"
+)
+
+type variablesTest struct {
+	decls  string
+	args   string
+	vars   string
+	expect map[string]interface{} // nil if the variables are invalid
+}
+
+var variablesTests = map[string]variablesTest{
+	"Scalars": {`$text: String!, $limit: Int, $id: ID`, `text: $text, limit: $limit, id: $id`,
+		`{"text": "luke", "limit": 10, "id": 1000}`,
+		map[string]interface{}{"text": "luke", "limit": 10, "id": "1000"}},
+	"Lists": {`$ids: [ID], $scores: [[Float]]`, `text: "", ids: $ids, scores: $scores`,
+		`{"ids": ["1", 2], "scores": [[1, 2.5], [3]]}`,
+		map[string]interface{}{
+			"text":   "",
+			"ids":    []interface{}{"1", "2"},
+			"scores": []interface{}{[]interface{}{1.0, 2.5}, []interface{}{3.0}},
+		}},
+	"LargeID": {`$id: ID`, `text: "", id: $id`, `{"id": 12345678901234567890}`,
+		map[string]interface{}{"text": "", "id": "12345678901234567890"}},
+	"SingleItemList": {`$ids: [ID]`, `text: "", ids: $ids`, `{"ids": 5}`,
+		map[string]interface{}{"text": "", "ids": []interface{}{"5"}}},
+	"Enum": {`$episode: Episode`, `text: "", episode: $episode`, `{"episode": "JEDI"}`,
+		map[string]interface{}{"text": "", "episode": "JEDI"}},
+	"InputObject": {`$criteria: Criteria!`, `text: "", criteria: $criteria`,
+		`{"criteria": {"name": "luke", "near": {"lat": 1, "lng": 2.5}}}`,
+		map[string]interface{}{"text": "", "criteria": map[string]interface{}{
+			"name":     "luke",
+			"episodes": []interface{}{"NEWHOPE"},
+			"near":     map[string]interface{}{"lat": 1.0, "lng": 2.5},
+		}}},
+	"NestedVariable": {`$name: String!`, `text: "", criteria: {name: $name}`, `{"name": "luke"}`,
+		map[string]interface{}{"text": "", "criteria": map[string]interface{}{
+			"name": "luke", "episodes": []interface{}{"NEWHOPE"},
+		}}},
+	"Default": {`$limit: Int = 5, $text: String! = "luke"`, `text: $text, limit: $limit`, `{}`,
+		map[string]interface{}{"text": "luke", "limit": 5}},
+	"NullOverridesDefault": {`$limit: Int = 5`, `text: "", limit: $limit`, `{"limit": null}`,
+		map[string]interface{}{"text": "", "limit": nil}},
+	"Omitted": {`$limit: Int`, `text: "", limit: $limit`, `{}`,
+		map[string]interface{}{"text": ""}},
+	"Undeclared": {``, `text: ""`, `{"limit": "ignored"}`,
+		map[string]interface{}{"text": ""}},
+
+	"MissingRequired":    {`$text: String!`, `text: $text`, `{}`, nil},
+	"NullRequired":       {`$text: String!`, `text: $text`, `{"text": null}`, nil},
+	"NullForRequiredArg": {`$text: String`, `text: $text`, `{"text": null}`, nil},
+	"WrongType":          {`$limit: Int`, `text: "", limit: $limit`, `{"limit": "10"}`, nil},
+	"Fraction":           {`$limit: Int`, `text: "", limit: $limit`, `{"limit": 1.5}`, nil},
+	"Overflow":           {`$limit: Int`, `text: "", limit: $limit`, `{"limit": 3000000000}`, nil},
+	"UnknownEnumValue":   {`$episode: Episode`, `text: "", episode: $episode`, `{"episode": "PHANTOM"}`, nil},
+	"EnumFromNumber":     {`$episode: Episode`, `text: "", episode: $episode`, `{"episode": 1}`, nil},
+	"UnknownField":       {`$criteria: Criteria`, `text: "", criteria: $criteria`, `{"criteria": {"name": "", "age": 5}}`, nil},
+	"MissingField":       {`$criteria: Criteria`, `text: "", criteria: $criteria`, `{"criteria": {}}`, nil},
+	"NullItem":           {`$ids: [ID!]`, `text: "", ids: $ids`, `{"ids": ["1", null]}`, nil},
+	"IncompatibleType":   {`$limit: String`, `text: "", limit: $limit`, `{"limit": "10"}`, nil},
+	"IncompatibleItem":   {`$stops: [Location]`, `text: "", criteria: {name: "", stops: $stops}`, `{"stops": []}`, nil},
+	"ListForItem":        {`$ids: [ID]`, `text: "", id: $ids`, `{"ids": ["1"]}`, nil},
+	"OutputType":         {`$result: Result`, `text: ""`, `{}`, nil},
+	"UnknownType":        {`$name: Name`, `text: ""`, `{}`, nil},
+	"InvalidDefault":     {`$limit: Int = "5"`, `text: "", limit: $limit`, `{}`, nil},
+}
+
+func TestVariables(t *testing.T) {
+	for name, test := range variablesTests {
+		sch := newTestSchema(t, coerceSchema, "Query", "")
+
+		var args map[string]interface{}
+		sch.AddResolveFunc("Result", func(r *ResponseNode) {
+			args = r.Args
+			r.Set("value", "")
+		})
+		sch.Finalize()
+
+		query := "{ search(" + test.args + ") { value } }"
+		if test.decls != "" {
+			query = "query Search(" + test.decls + ") " + query
+		}
+
+		doc, err := ast.FromReader(strings.NewReader(query))
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		var vars map[string]interface{}
+		if err := unmarshalJSON([]byte(test.vars), &vars); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		active := ""
+		if test.decls != "" {
+			active = "Search"
+		}
+
+		_, err = ExecuteWithVariables(context.Background(), sch, &doc, active, vars)
+		if test.expect == nil {
+			if err == nil {
+				t.Errorf("%s: Expected an error, got %v", name, args)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+		} else if !reflect.DeepEqual(args, test.expect) {
+			t.Errorf("%s: Expected %v, got %v", name, test.expect, args)
+		}
+	}
+}
+
+func TestInvalidVariablesNotExecuted(t *testing.T) {
+	sch := newTestSchema(t, coerceSchema, "Query", "")
+	sch.AddResolveFunc("Result", func(r *ResponseNode) {
+		t.Error("Operation executed with invalid variables")
+	})
+	sch.Finalize()
+
+	doc, _ := ast.FromReader(strings.NewReader(`query Search($text: String!, $limit: Int) { search(text: $text, limit: $limit) { value } }`))
+	ctx, err := ExecuteWithVariables(context.Background(), sch, &doc, "Search", map[string]interface{}{"limit": "ten"})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	if ctx.Response != nil {
+		t.Error("Expected no response")
+	}
+	if len(ctx.Errors) != 2 {
+		t.Errorf("Expected an error for each invalid variable, got %v", ctx.Errors)
+	}
+}
+
+func TestParseVariablesFromJSON(t *testing.T) {
+	doc, _ := ast.FromReader(strings.NewReader(`query Search($limit: Int = 5, $ids: [ID!]) { search(text: "") { value } }`))
+
+	for vars, expect := range map[string]map[string]interface{}{
+		``:                  {"limit": 5},
+		`{"ids": [1, "2"]}`: {"limit": 5, "ids": []interface{}{"1", "2"}},
+		`{"limit": null}`:   {"limit": nil},
+		`[]`:                nil,
+		`{"ids": [true]}`:   nil,
+	} {
+		ctx := NewContext(newTestSchema(t, coerceSchema, "Query", ""))
+		ctx.processDefinitions(&doc, "Search")
+
+		err := ctx.ParseVariablesFromJSON(vars)
+		if expect == nil {
+			if err == nil {
+				t.Errorf("%s: Expected an error", vars)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", vars, err)
+			continue
+		}
+
+		actual := make(map[string]interface{})
+		for name, v := range ctx.Variables {
+			actual[name] = v.Value()
+		}
+		if !reflect.DeepEqual(actual, expect) {
+			t.Errorf("%s: Expected %v, got %v", vars, expect, actual)
+		}
+	}
+}
+
+func TestVariableDirective(t *testing.T) {
+	sch := newTestSchema(t, bankSchema, "Query", "Mutation")
+	sch.AddResolveFunc("Account", func(r *ResponseNode) {
+		r.Set("balance", 10)
+	})
+	sch.AddResolveFunc("Owner", func(r *ResponseNode) {
+		r.Set("name", "Luke")
+	})
+	sch.Finalize()
+
+	doc, _ := ast.FromReader(strings.NewReader(`query Account($brief: Boolean!) { account { balance owner @skip(if: $brief) { name } } }`))
+	for brief, expect := range map[bool]string{
+		true:  `{"account":{"balance":10}}`,
+		false: `{"account":{"balance":10,"owner":{"name":"Luke"}}}`,
+	} {
+		ctx, err := ExecuteWithVariables(context.Background(), sch, &doc, "Account", map[string]interface{}{"brief": brief})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual, _ := ctx.Response.MarshalJSON(); string(actual) != expect {
+			t.Errorf("Expected '%s', got '%s'", expect, actual)
+		}
+	}
+}
+
+func TestCustomScalarVariable(t *testing.T) {
+	sch := newTestSchema(t, scalarSchema, "Query", "")
+	sch.AddScalar("DateTime", dateTime)
+	sch.AddResolveFunc("Event", func(r *ResponseNode) {
+		after, _ := r.Args.Get("after")
+		r.Set("at", after.(time.Time).Add(time.Hour))
+	})
+	sch.Finalize()
+
+	doc, _ := ast.FromReader(strings.NewReader(`query Next($after: DateTime!) { next(after: $after) { at } }`))
+	ctx, err := ExecuteWithVariables(context.Background(), sch, &doc, "Next", map[string]interface{}{"after": "2016-01-02T15:04:05Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual, _ := ctx.Response.MarshalJSON(); string(actual) != `{"next":{"at":"2016-01-02T16:04:05Z"}}` {
+		t.Errorf("Unexpected response '%s'", actual)
+	}
+
+	if _, err := ExecuteWithVariables(context.Background(), sch, &doc, "Next", map[string]interface{}{"after": "yesterday"}); err == nil {
+		t.Error("Expected an error for an invalid DateTime")
+	}
+}
+
+var jsonSchema = `
+scalar JSON
+scalar Tag
+
+type Tagged {
+  tag: Tag
+}
+
+type Query {
+  tagged(tag: Tag, data: JSON): Tagged
+}
+`
+
+func TestCustomScalarVariableInLiteral(t *testing.T) {
+	sch := newTestSchema(t, jsonSchema, "Query", "")
+
+	parsed := 0
+	sch.AddScalar("Tag", Scalar{
+		ParseValue: func(v interface{}) (interface{}, error) {
+			parsed++
+			return []interface{}{v}, nil
+		},
+		Serialize: func(v interface{}) (interface{}, error) { return v, nil },
+	})
+	sch.AddScalar("JSON", Scalar{
+		ParseValue: func(v interface{}) (interface{}, error) { return v, nil },
+		Serialize:  func(v interface{}) (interface{}, error) { return v, nil },
+	})
+	sch.AddResolveFunc("Tagged", func(r *ResponseNode) {
+		tag, _ := r.Args.Get("tag")
+		data, _ := r.Args.Get("data")
+		if expect := []interface{}{"a"}; !reflect.DeepEqual(tag, expect) {
+			t.Errorf("Expected tag %v, got %v", expect, tag)
+		}
+		if expect := map[string]interface{}{"tags": []interface{}{tag}}; !reflect.DeepEqual(data, expect) {
+			t.Errorf("Expected data %v, got %v", expect, data)
+		}
+		r.Set("tag", tag)
+	})
+	sch.Finalize()
+
+	doc, _ := ast.FromReader(strings.NewReader(`query Tagged($tag: Tag) { tagged(tag: $tag, data: {tags: [$tag]}) { tag } }`))
+	if _, err := ExecuteWithVariables(context.Background(), sch, &doc, "Tagged", map[string]interface{}{"tag": "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed != 1 {
+		t.Errorf("Expected the variable to be parsed once, got %d", parsed)
+	}
+}
+
+func TestHandlerVariables(t *testing.T) {
+	sch := newTestSchema(t, bankSchema, "Query", "Mutation")
+	sch.AddResolveFunc("Account", func(r *ResponseNode) {
+		amount, _ := r.Args.Get("amount")
+		r.Set("balance", amount)
+	})
+
+	h := sch.Handler()
+
+	body := `{
+		"query": "mutation Deposit($amount: Int!) { deposit(amount: $amount) { balance } }",
+		"variables": {"amount": 25},
+		"operationName": "Deposit"
+	}`
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if expect := `{"data":{"deposit":{"balance":25}}}`; w.Body.String() != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, w.Body.String())
+	}
+
+	q := url.Values{
+		"query":     {"query Account($amount: Int!) { account { balance } }"},
+		"variables": {"25"},
+	}
+	req = httptest.NewRequest("GET", "/graphql?"+q.Encode(), nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for invalid variables, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestHandlerLargeID(t *testing.T) {
+	sch := newTestSchema(t, coerceSchema, "Query", "")
+	sch.AddResolveFunc("Result", func(r *ResponseNode) {
+		id, _ := r.Args.Get("id")
+		r.Set("value", id)
+	})
+
+	body := `{
+		"query": "query Search($id: ID) { search(text: \"\", id: $id) { value } }",
+		"variables": {"id": 12345678901234567890},
+		"operationName": "Search"
+	}`
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	sch.Handler().ServeHTTP(w, req)
+
+	if expect := `{"data":{"search":{"value":"12345678901234567890"}}}`; w.Body.String() != expect {
+		t.Errorf("Expected '%s', got '%s'", expect, w.Body.String())
+	}
+}